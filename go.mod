@@ -3,8 +3,10 @@ module github.com/o7q2ab/goxm
 go 1.24.4
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/shirou/gopsutil/v4 v4.25.6
 	github.com/spf13/cobra v1.9.1
+	github.com/ulikunitz/xz v0.5.15
 	golang.org/x/mod v0.25.0
 )
 
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmpolicy"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+// Exit codes of goxm.
+const (
+	ExitOK = 0
+	// ExitViolations means the command succeeded, but found policy violations.
+	ExitViolations = 1
+	// ExitError means the command failed.
+	ExitError = 2
+)
+
+// ErrViolations is returned by the check command when any of the targets violates the policy.
+var ErrViolations = errors.New("policy violations found")
+
+// ExitCode returns the exit code for the error returned by the command.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrViolations):
+		return ExitViolations
+	}
+	return ExitError
+}
+
+func newCheckCmd() *cobra.Command {
+	var policyFile string
+	var walk walkFlags
+
+	c := &cobra.Command{
+		Use:   "check --policy <file-path> " + targetsUsage + "...",
+		Short: "Check Go binaries, processes and modules against a policy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths, err := walk.paths(cmd, args)
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				return errNoPaths
+			}
+			walkOpts, err := walk.options()
+			if err != nil {
+				return err
+			}
+			policy, err := xmpolicy.Load(policyFile)
+			if err != nil {
+				return err
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{Command: "check", ShowViolations: true})
+			if err != nil {
+				return err
+			}
+			violated := false
+			for _, arg := range paths {
+				targets, err := loadTargets(arg, walkOpts, getJobs(cmd))
+				if err != nil {
+					return err
+				}
+				for _, t := range targets {
+					vs := policy.Check(t)
+					switch t := t.(type) {
+					case *xmreport.Binary:
+						t.Violations = vs
+					case *xmreport.Process:
+						t.Violations = vs
+					case *xmreport.GoMod:
+						t.Violations = vs
+					}
+					violated = violated || len(vs) != 0
+					if err := w.Write(t); err != nil {
+						return err
+					}
+				}
+			}
+			if err := w.Close(); err != nil {
+				return err
+			}
+			if violated {
+				return ErrViolations
+			}
+			return nil
+		},
+	}
+
+	c.Flags().StringVar(
+		&policyFile, "policy", "", "JSON file with the policy",
+	)
+	c.MarkFlagRequired("policy")
+	addWalkFlags(c, &walk)
+
+	return c
+}
@@ -1,20 +1,18 @@
 package commands
 
 import (
-	"debug/buildinfo"
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
-	"strconv"
-	"strings"
+	"slices"
 
-	"github.com/shirou/gopsutil/v4/process"
 	"github.com/spf13/cobra"
 
 	"github.com/o7q2ab/goxm/internal/build"
 	"github.com/o7q2ab/goxm/internal/xmmod"
 	"github.com/o7q2ab/goxm/internal/xmpath"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
 )
 
 const (
@@ -32,7 +30,12 @@ const (
 
 func NewRootCmd() *cobra.Command {
 	c := &cobra.Command{
-		Use: "goxm",
+		Use:           "goxm",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return configureLookups(cmd)
+		},
 		Run: func(*cobra.Command, []string) {
 			fmt.Printf(
 				logo,
@@ -41,29 +44,111 @@ func NewRootCmd() *cobra.Command {
 			)
 		},
 	}
+	addOutputFlag(c)
+	addLookupFlags(c)
+	addJobsFlag(c)
+	addVulnDBFlag(c)
 	c.AddCommand(
 		newBinaryCmd(),
 		newPathCmd(),
 		newProcCmd(),
+		newImageCmd(),
 		newModuleCmd(),
+		newVulnCmd(),
+		newSBOMCmd(),
+		newSizeCmd(),
+		newDiffCmd(),
+		newSnapshotCmd(),
+		newReportCmd(),
+		newCheckCmd(),
+		newCacheCmd(),
 	)
 	return c
 }
 
 func newBinaryCmd() *cobra.Command {
-	var showDeps, showLatest, showBuildSettings bool
+	var showDeps, showLatest, showBuildSettings, showVulns bool
+	var showPackages, showFiles, showHardening bool
+	var filter updateFilter
+	var table tableFlags
+	var walk walkFlags
 
 	c := &cobra.Command{
-		Use:     "binary [<file-path> | <dir-path>]",
+		Use:     "binary [<file-path> | <dir-path> | -]...",
 		Aliases: []string{"bin", "b"},
-		Short:   "Examine binary file(s) at given path",
-		Run: func(cmd *cobra.Command, args []string) {
-			printFiles(
-				xmpath.List(args[0]),
-				showDeps,
-				showLatest,
-				showBuildSettings,
-			)
+		Short:   "Examine binary file(s) at given path(s)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listed, err := walk.listed(cmd, args)
+			if err != nil {
+				return err
+			}
+			if len(args)+len(listed) == 0 {
+				return errNoPaths
+			}
+			walkOpts, err := walk.options()
+			if err != nil {
+				return err
+			}
+			jobs := getJobs(cmd)
+			readOpts := xmsource.Options{Packages: showPackages, Files: showFiles, Hardening: showHardening}
+
+			// The files given as the arguments must be Go binaries or archives, the ones
+			// in the directories and the listed ones are skipped if they are not.
+			var bins []*xmreport.Binary
+			var names []string
+			files := 0
+			for _, arg := range args {
+				if stat, err := os.Stat(arg); err == nil && stat.IsDir() {
+					names = append(names, xmpath.Walk(arg, walkOpts)...)
+					continue
+				}
+				found, err := readFile(arg, readOpts)
+				if err != nil {
+					return err
+				}
+				bins = append(bins, found...)
+				files++
+			}
+			for _, p := range listed {
+				names = append(names, xmpath.Walk(p, walkOpts)...)
+			}
+			names = uniqueNames(names)
+			bins = append(bins, readBinaries(names, readOpts, jobs)...)
+
+			keep, err := filter.keep(showDeps && showLatest)
+			if err != nil {
+				return err
+			}
+			w, err := newWriter(cmd, xmreport.Options{
+				Command:       "binary",
+				Short:         len(names)+files == 1 && !slices.ContainsFunc(bins, fromArchive),
+				ShowDeps:      showDeps,
+				ShowLatest:    showLatest,
+				ShowBuild:     showBuildSettings,
+				ShowVulns:     showVulns,
+				ShowPkgs:      showPackages || showFiles,
+				ShowFiles:     showFiles,
+				ShowHardening: showHardening,
+				Table:         table.options(),
+				Filter:        keep,
+			})
+			if err != nil {
+				return err
+			}
+			db, err := vulnDBIf(cmd, showVulns)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return printBinaries(w, bins, scanOptions{
+				showDeps:          showDeps,
+				showLatest:        showLatest,
+				showBuildSettings: showBuildSettings,
+				lookupMain:        table.needsLatest(),
+				lookupNotices:     table.options() == nil,
+				jobs:              jobs,
+				vulndb:            db,
+			})
 		},
 	}
 
@@ -76,211 +161,92 @@ func newBinaryCmd() *cobra.Command {
 	c.Flags().BoolVarP(
 		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
 	)
-
-	return c
-}
-
-func newPathCmd() *cobra.Command {
-	var showDeps, showLatest, showBuildSettings bool
-
-	c := &cobra.Command{
-		Use:   "path",
-		Short: "Examine all Go binaries found in directories added to PATH environment variable",
-		Run: func(cmd *cobra.Command, args []string) {
-			printFiles(
-				xmpath.ListPathEnv(),
-				showDeps,
-				showLatest,
-				showBuildSettings,
-			)
-		},
-	}
-
-	c.Flags().BoolVarP(
-		&showDeps, "deps", "d", false, "show all the dependency modules",
+	c.Flags().BoolVar(
+		&showPackages, "packages", false, "show the Go packages linked into the binary, grouped by module",
 	)
 	c.Flags().BoolVar(
-		&showLatest, "latest", false, "show latest versions for all the dependency modules",
+		&showFiles, "files", false, "show the source files of the linked packages (implies --packages)",
 	)
-	c.Flags().BoolVarP(
-		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
+	c.Flags().BoolVar(
+		&showHardening, "hardening", false, "show the security properties of the executable: PIE, RELRO, NX stack, symbols, linking",
 	)
+	addVulnFlag(c, &showVulns)
+	addUpdateFlags(c, &filter)
+	addTableFlags(c, &table)
+	addWalkFlags(c, &walk)
 
 	return c
 }
 
-func newProcCmd() *cobra.Command {
-	var showDeps, showBuildSettings, showConn bool
-	var filter string
-
-	addrFamilies := []string{
-		"AF_UNSPEC",
-		"AF_UNIX",
-		"AF_INET",
-		"AF_AX25",
-		"AF_IPX",
-		"AF_APPLETALK",
-		"AF_NETROM",
-		"AF_BRIDGE",
-		"AF_ATMPVC",
-		"AF_X25",
-		"AF_INET6",
-		"AF_ROSE",
-		"AF_DECnet",
-		"AF_NETBEUI",
-		"AF_SECURITY",
-		"AF_KEY",
-		"AF_NETLINK",
-		"AF_PACKET",
-		"AF_ASH",
-		"AF_ECONET",
-		"AF_ATMSVC",
-		"AF_RDS",
-		"AF_SNA",
-		"AF_IRDA",
-		"AF_PPPOX",
-		"AF_WANPIPE",
-		"AF_LLC",
-		"AF_IB",
-		"AF_MPLS",
-		"AF_CAN",
-		"AF_TIPC",
-		"AF_BLUETOOTH",
-		"AF_IUCV",
-		"AF_RXRPC",
-		"AF_ISDN",
-		"AF_PHONET",
-		"AF_IEEE802154",
-		"AF_CAIF",
-		"AF_ALG",
-		"AF_NFC",
-		"AF_VSOCK",
-		"AF_KCM",
-		"AF_QIPCRTR",
-		"AF_SMC",
-		"AF_XDP",
-		"AF_MCTP",
-	}
+func newPathCmd() *cobra.Command {
+	var showDeps, showLatest, showBuildSettings, showVulns bool
+	var filter updateFilter
+	var table tableFlags
 
 	c := &cobra.Command{
-		Use:     "process [<pid>]",
-		Aliases: []string{"proc", "ps", "p"},
-		Short:   "Examine currently running Go processes",
-		Run: func(cmd *cobra.Command, args []string) {
-			var all []*process.Process
-			var err error
-			if len(args) == 0 {
-				all, err = process.Processes()
-				if err != nil {
-					fmt.Println("error:", err)
-					return
-				}
-			} else {
-				pid, err := strconv.Atoi(args[0])
-				if err != nil {
-					fmt.Println("error:", err)
-					return
-				}
-				pr, err := process.NewProcess(int32(pid))
-				if err != nil {
-					fmt.Println("error:", err)
-					return
-				}
-				all = []*process.Process{pr}
-				filter = ""
+		Use:   "path",
+		Short: "Examine all Go binaries found in directories added to PATH environment variable",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := xmpath.ListPathEnv()
+			keep, err := filter.keep(showDeps && showLatest)
+			if err != nil {
+				return err
 			}
-
-			idx := 0
-			for _, p := range all {
-				path, err := p.Exe()
-				if err != nil {
-					continue
-				}
-				info, err := buildinfo.ReadFile(path)
-				if err != nil {
-					continue
-				}
-
-				if filter != "" && !strings.Contains(info.Main.Path, filter) {
-					continue
-				}
-
-				if idx != 0 {
-					fmt.Println("---------------")
-				}
-				idx++
-
-				name, err := p.Name()
-				if err != nil {
-					name = err.Error()
-				}
-
-				fmt.Printf("%d | %s [%d]\n", idx, name, p.Pid)
-
-				fmt.Printf(
-					"%s [%s | %d deps | mod: %s]\n",
-					info.Path, info.GoVersion, len(info.Deps), info.Main.Path,
-				)
-
-				if showDeps {
-					fmt.Printf("\nDependencies:\n")
-					for _, d := range info.Deps {
-						fmt.Printf("    %s %s\n", d.Path, d.Version)
-					}
-				}
-
-				if showBuildSettings {
-					fmt.Printf("\nBuild settings:\n")
-					for _, s := range info.Settings {
-						fmt.Printf("    %s=%s\n", s.Key, s.Value)
-					}
-				}
-
-				if showConn {
-					fmt.Printf("\nConnections:\n")
-					conns, err := p.Connections()
-					if err != nil {
-						fmt.Println("    error:", err)
-						continue
-					}
-					if len(conns) == 0 {
-						fmt.Println("    no connections")
-					}
-					for _, c := range conns {
-						fmt.Printf(
-							"    %s %s:%d - %s:%d\n",
-							addrFamilies[c.Family],
-							c.Laddr.IP, c.Laddr.Port,
-							c.Raddr.IP, c.Raddr.Port,
-						)
-					}
-				}
+			w, err := newWriter(cmd, xmreport.Options{
+				Command:    "path",
+				Short:      len(names) == 1,
+				ShowDeps:   showDeps,
+				ShowLatest: showLatest,
+				ShowBuild:  showBuildSettings,
+				ShowVulns:  showVulns,
+				Table:      table.options(),
+				Filter:     keep,
+			})
+			if err != nil {
+				return err
+			}
+			db, err := vulnDBIf(cmd, showVulns)
+			if err != nil {
+				return err
 			}
+			defer db.Close()
+			return printFiles(w, names, scanOptions{
+				showDeps:          showDeps,
+				showLatest:        showLatest,
+				showBuildSettings: showBuildSettings,
+				lookupMain:        table.needsLatest(),
+				lookupNotices:     table.options() == nil,
+				jobs:              getJobs(cmd),
+				vulndb:            db,
+			})
 		},
 	}
 
 	c.Flags().BoolVarP(
 		&showDeps, "deps", "d", false, "show all the dependency modules",
 	)
-	c.Flags().BoolVarP(
-		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
-	)
 	c.Flags().BoolVar(
-		&showConn, "conn", false, "show all the connections (TCP, UDP, Unix) used by the process",
+		&showLatest, "latest", false, "show latest versions for all the dependency modules",
 	)
-	c.Flags().StringVar(
-		&filter, "filter", "", "filter by the package name",
+	c.Flags().BoolVarP(
+		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
 	)
+	addVulnFlag(c, &showVulns)
+	addUpdateFlags(c, &filter)
+	addTableFlags(c, &table)
 
 	return c
 }
 
 func newModuleCmd() *cobra.Command {
+	var filter updateFilter
+	var showVulns bool
+
 	c := &cobra.Command{
 		Use:     "module [<file-path>]",
 		Aliases: []string{"mod", "m"},
 		Short:   "Examine Go module",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var p string
 			if len(args) != 0 {
 				p = args[0]
@@ -290,34 +256,48 @@ func newModuleCmd() *cobra.Command {
 
 			p, err := xmmod.Find(p)
 			if err != nil {
-				fmt.Println("error:", err)
-				return
+				return err
 			}
 
+			keep, err := filter.keep(true)
+			if err != nil {
+				return err
+			}
+			w, err := newWriter(cmd, xmreport.Options{Command: "module", ShowVulns: showVulns, Filter: keep})
+			if err != nil {
+				return err
+			}
+			db, err := vulnDBIf(cmd, showVulns)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
 			modf, err := xmmod.Read(p)
 			if err != nil {
-				fmt.Println("error:", err)
-				return
+				return err
 			}
-			fmt.Println("Module root dir:", filepath.Dir(p))
-			fmt.Println(modf.Module.Mod.Path)
-			for _, r := range modf.Require {
-				latest := xmmod.GetLatest(r.Mod.Path)
-				suffix := ""
-				if latest == "" {
-					suffix = " (latest: unknown)"
-				} else if latest != r.Mod.Version {
-					suffix = fmt.Sprintf(" (latest: %s)", latest)
-				}
-				if r.Indirect {
-					fmt.Printf("    [indirect] %s%s\n", r.Mod, suffix)
-				} else {
-					fmt.Printf("    %s%s\n", r.Mod, suffix)
+			m := xmreport.NewGoMod(p, modf)
+			mods := make([]*xmreport.Module, 0, len(m.Requires))
+			for _, r := range m.Requires {
+				mods = append(mods, r.Module)
+			}
+			lookupModules(mods, true, getJobs(cmd))
+			m.Summary = xmreport.Summarize(mods)
+			if db != nil {
+				if err := checkVulns(db, m); err != nil {
+					return err
 				}
 			}
+			if err := w.Write(m); err != nil {
+				return err
+			}
+			return w.Close()
 		},
 	}
 
+	addVulnFlag(c, &showVulns)
+	addUpdateFlags(c, &filter)
 	c.AddCommand(
 		newModuleFindCmd(),
 	)
@@ -326,99 +306,86 @@ func newModuleCmd() *cobra.Command {
 }
 
 func newModuleFindCmd() *cobra.Command {
+	var table tableFlags
+
 	c := &cobra.Command{
 		Use:     "find [<dir-path>]",
 		Aliases: []string{"f"},
 		Short:   "Find Go modules",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var p string
 			if len(args) != 0 {
 				p = args[0]
 			} else {
 				p, _ = os.Getwd()
 			}
-			all, err := xmmod.FindAll(p)
+			all, err := findModules(cmd, p)
 			if err != nil {
-				fmt.Println("error:", err)
+				return err
 			}
 
-			fmt.Printf("Found %d go.mod files.\n\n", len(all))
-			for i, one := range all {
-				if i != 0 {
-					fmt.Println("---------------")
-				}
+			w, err := newWriter(cmd, xmreport.Options{Command: "module find", Root: p, Table: table.options()})
+			if err != nil {
+				return err
+			}
+
+			for _, one := range all {
+				var m *xmreport.GoMod
 				modf, err := xmmod.Read(one)
 				if err != nil {
-					fmt.Println("error:", err)
-					continue
+					m = &xmreport.GoMod{File: one, Error: err.Error()}
+				} else {
+					m = xmreport.NewGoMod(one, modf)
 				}
-				d, err := filepath.Rel(p, filepath.Dir(one))
-				if err != nil {
-					d = filepath.Dir(one)
+				if err := w.Write(m); err != nil {
+					return err
 				}
-				fmt.Println("- Module root dir:", d)
-				fmt.Printf("  %s [go %s | %d deps]\n", modf.Module.Mod.Path, modf.Go.Version, len(modf.Require))
 			}
+			return w.Close()
 		},
 	}
+	addTableFlags(c, &table)
 	return c
 }
 
-func printFiles(names []string, showDeps, showLatest, showBuildSettings bool) {
-	short := len(names) == 1
+// findModules returns the go.mod files under dir, printing the errors of the
+// directories that cannot be read as warnings.
+func findModules(cmd *cobra.Command, dir string) ([]string, error) {
+	mods, skipped, err := xmmod.FindAll(dir)
+	for _, err := range skipped {
+		fmt.Fprintln(cmd.ErrOrStderr(), "warning:", err)
+	}
+	return mods, err
+}
 
-	idx := 0
-	for _, name := range names {
-		info, err := buildinfo.ReadFile(name)
-		if err != nil {
-			continue
-		}
+func printFiles(w xmreport.Writer, names []string, opts scanOptions) error {
+	return printBinaries(w, readBinaries(names, xmsource.Options{}, opts.jobs), opts)
+}
 
-		if idx != 0 {
-			fmt.Println("---------------")
+func printBinaries(w xmreport.Writer, bins []*xmreport.Binary, opts scanOptions) error {
+	var mods []*xmreport.Module
+	for _, b := range bins {
+		if opts.showDeps || opts.showBuildSettings || opts.lookupMain {
+			mods = append(mods, b.Main)
 		}
-		idx++
-
-		if short {
-			fmt.Printf(
-				"%s [%s | %d deps | mod: %s]\n",
-				info.Path, info.GoVersion, len(info.Deps), info.Main.Path,
-			)
-		} else {
-			fmt.Printf(
-				"%d | %s\n%s [%s | %d deps | mod: %s]\n",
-				idx, name, info.Path, info.GoVersion, len(info.Deps), info.Main.Path,
-			)
+		if opts.showDeps && opts.showLatest {
+			mods = append(mods, b.Deps...)
 		}
+	}
+	lookupModules(mods, opts.lookupNotices, opts.jobs)
 
-		if showDeps || showBuildSettings {
-			latest := xmmod.GetLatest(info.Main.Path)
-			fmt.Printf("\ncurrent: %s\nlatest: %s\n", info.Main.Version, latest)
+	for _, b := range bins {
+		if opts.showDeps && opts.showLatest {
+			b.Summary = xmreport.Summarize(b.Deps)
 		}
-		if showDeps {
-			fmt.Printf("\nDependencies:\n")
-			for _, d := range info.Deps {
-				suffix := ""
-				if showLatest {
-					latest := xmmod.GetLatest(d.Path)
-					if latest == "" {
-						suffix = " (latest: unknown)"
-					} else {
-						suffix = fmt.Sprintf(" (latest: %s)", latest)
-					}
-				}
-				fmt.Printf("    %s %s%s\n", d.Path, d.Version, suffix)
+		if opts.vulndb != nil {
+			if err := checkVulns(opts.vulndb, b); err != nil {
+				return err
 			}
 		}
-		if showBuildSettings {
-			fmt.Printf("\nBuild settings:\n")
-			for _, s := range info.Settings {
-				fmt.Printf("    %s=%s\n", s.Key, s.Value)
-			}
+		if err := w.Write(b); err != nil {
+			return err
 		}
 	}
-
-	if idx == 0 {
-		fmt.Println("No Go binary files were found.")
-	}
+	return w.Close()
 }
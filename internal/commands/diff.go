@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmpath"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+func newDiffCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Compare the build information of two Go binaries or processes",
+		Long: `Compare the build information of two Go binaries or processes.
+Each of <old> and <new> is a binary file or the ID of a running Go process.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			old, oldName, err := loadBinary(args[0])
+			if err != nil {
+				return err
+			}
+			new, newName, err := loadBinary(args[1])
+			if err != nil {
+				return err
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{Command: "diff"})
+			if err != nil {
+				return err
+			}
+			if err := w.Write(xmreport.Compare(oldName, old, newName, new)); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+	return c
+}
+
+// loadBinary reads the binary file or the executable of the process with the given ID.
+// It returns the name describing the binary too.
+func loadBinary(arg string) (*xmreport.Binary, string, error) {
+	targets, err := loadTargets(arg, &xmpath.Options{}, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(targets) != 1 {
+		return nil, "", fmt.Errorf("%s: expected a single Go binary, found %d", arg, len(targets))
+	}
+	switch t := targets[0].(type) {
+	case *xmreport.Binary:
+		return t, t.File, nil
+	case *xmreport.Process:
+		return t.Binary, fmt.Sprintf("%s (pid %d)", t.File, t.PID), nil
+	}
+	return nil, "", fmt.Errorf("%s: not a Go binary or process", arg)
+}
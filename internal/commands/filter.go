@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmmod"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+var errFilterNeedsLatest = errors.New("--min-age and --only need --deps and --latest to look up the updates of the dependency modules")
+
+// updateFilter selects the dependency modules by their available updates.
+type updateFilter struct {
+	minAge int
+	only   []string
+}
+
+func addUpdateFlags(c *cobra.Command, f *updateFilter) {
+	c.Flags().IntVar(
+		&f.minAge, "min-age", 0, "show only the modules at least this many days behind their latest versions",
+	)
+	c.Flags().StringSliceVar(
+		&f.only, "only", nil,
+		fmt.Sprintf("show only the modules with these kinds of updates: %s", strings.Join(xmmod.UpdateKinds, ", ")),
+	)
+}
+
+// keep returns the filter function, or nil if no filtering was asked for. The latest
+// versions of the dependency modules must be looked up for the filter to tell their
+// updates, which latest reports.
+func (f *updateFilter) keep(latest bool) (func(*xmreport.Module) bool, error) {
+	for _, kind := range f.only {
+		if !slices.Contains(xmmod.UpdateKinds, kind) {
+			return nil, fmt.Errorf("unknown kind of update %q", kind)
+		}
+	}
+	if f.minAge <= 0 && len(f.only) == 0 {
+		return nil, nil
+	}
+	if !latest {
+		return nil, errFilterNeedsLatest
+	}
+	return func(m *xmreport.Module) bool {
+		if len(f.only) != 0 && !slices.ContainsFunc(f.only, m.HasUpdate) {
+			return false
+		}
+		return m.DaysBehind >= f.minAge
+	}, nil
+}
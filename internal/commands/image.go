@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmimage"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+func newImageCmd() *cobra.Command {
+	var showDeps, showLatest, showBuildSettings, showVulns bool
+	var filter updateFilter
+	var table tableFlags
+
+	c := &cobra.Command{
+		Use:   "image <docker-save-tar> | <oci-layout> | -...",
+		Short: "Examine Go binaries in container images saved to local files",
+		Long: `Examine Go binaries in container images saved to local files.
+
+The arguments are tar files written by "docker save", or OCI image layouts (directories
+or tar files of them). "-" reads a tar file from the standard input. The layers are
+applied in order, honouring the whiteout files, and the Go binaries of the resulting
+file system are reported by their paths in it. No container daemon or registry is accessed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var bins []*xmreport.Binary
+			for _, arg := range args {
+				found, err := xmimage.Scan(arg)
+				if err != nil {
+					return err
+				}
+				bins = append(bins, found...)
+			}
+
+			keep, err := filter.keep(showDeps && showLatest)
+			if err != nil {
+				return err
+			}
+			w, err := newWriter(cmd, xmreport.Options{
+				Command:    "image",
+				ShowDeps:   showDeps,
+				ShowLatest: showLatest,
+				ShowBuild:  showBuildSettings,
+				ShowVulns:  showVulns,
+				Table:      table.options(),
+				Filter:     keep,
+			})
+			if err != nil {
+				return err
+			}
+			db, err := vulnDBIf(cmd, showVulns)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return printBinaries(w, bins, scanOptions{
+				showDeps:          showDeps,
+				showLatest:        showLatest,
+				showBuildSettings: showBuildSettings,
+				lookupMain:        table.needsLatest(),
+				lookupNotices:     table.options() == nil,
+				jobs:              getJobs(cmd),
+				vulndb:            db,
+			})
+		},
+	}
+
+	c.Flags().BoolVarP(
+		&showDeps, "deps", "d", false, "show all the dependency modules",
+	)
+	c.Flags().BoolVar(
+		&showLatest, "latest", false, "show latest versions for all the dependency modules",
+	)
+	c.Flags().BoolVarP(
+		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
+	)
+	addVulnFlag(c, &showVulns)
+	addUpdateFlags(c, &filter)
+	addTableFlags(c, &table)
+
+	return c
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmcache"
+	"github.com/o7q2ab/goxm/internal/xmmod"
+)
+
+func addLookupFlags(c *cobra.Command) {
+	c.PersistentFlags().Duration(
+		"cache-ttl", xmmod.DefaultCacheTTL, "how long looked up module versions are cached, 0 disables the cache",
+	)
+	c.PersistentFlags().Bool(
+		"refresh", false, "ignore the cached module versions and look them up again",
+	)
+	c.PersistentFlags().Int(
+		"rate", xmmod.DefaultRate, "maximum number of module proxy requests per second, 0 means no limit",
+	)
+	c.PersistentFlags().Bool(
+		"offline", false, "look up the latest versions in the local module cache only (implied by GOPROXY=off and GOFLAGS=-mod=mod)",
+	)
+}
+
+func configureLookups(cmd *cobra.Command) error {
+	ttl, err := cmd.Flags().GetDuration("cache-ttl")
+	if err != nil {
+		return err
+	}
+	refresh, err := cmd.Flags().GetBool("refresh")
+	if err != nil {
+		return err
+	}
+	rate, err := cmd.Flags().GetInt("rate")
+	if err != nil {
+		return err
+	}
+	offline, err := cmd.Flags().GetBool("offline")
+	if err != nil {
+		return err
+	}
+	xmmod.Configure(xmmod.Config{CacheTTL: ttl, Refresh: refresh, Rate: rate, Offline: offline})
+	return nil
+}
+
+func newCacheCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of looked up module versions",
+	}
+	c.AddCommand(
+		newCacheCleanCmd(),
+	)
+	return c
+}
+
+func newCacheCleanCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove all the cached module versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := xmcache.DefaultDir()
+			if err != nil {
+				return err
+			}
+			if err := (&xmcache.Cache{Dir: dir}).Clean(); err != nil {
+				return err
+			}
+			fmt.Println("Removed", dir)
+			return nil
+		},
+	}
+	return c
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+func addOutputFlag(c *cobra.Command) {
+	c.PersistentFlags().StringP(
+		"output", "o", xmreport.FormatText,
+		fmt.Sprintf("output format: %s", strings.Join(xmreport.Formats, ", ")),
+	)
+	c.PersistentFlags().String(
+		"format", "", "Go template executed for every examined binary, process or go.mod file, e.g. '{{.Path}} {{.GoVersion}}'",
+	)
+}
+
+var (
+	errFormatAndOutput = errors.New("--format and --output cannot be used together")
+	errTableOutput     = errors.New("--table, --columns, --sort and --group-by only apply to the text output")
+
+	// noFindings are the commands whose outputs have no findings to report in SARIF.
+	noFindings = []string{"diff", "snapshot diff", "size"}
+)
+
+// checkOutputUnused returns an error if --output or --format is set while what, the
+// command or one of its flags, writes its own output.
+func checkOutputUnused(cmd *cobra.Command, what string) error {
+	for _, name := range []string{"output", "format"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be used with %s", name, what)
+		}
+	}
+	return nil
+}
+
+func newWriter(cmd *cobra.Command, opts xmreport.Options) (xmreport.Writer, error) {
+	tmpl, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, err
+	}
+	if tmpl != "" {
+		if cmd.Flags().Changed("output") {
+			return nil, errFormatAndOutput
+		}
+		if opts.Table != nil {
+			return nil, errTableOutput
+		}
+		return xmreport.NewTemplateWriter(cmd.OutOrStdout(), tmpl, opts)
+	}
+
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return nil, err
+	}
+	if opts.Table != nil && format != xmreport.FormatText {
+		return nil, errTableOutput
+	}
+	if format == xmreport.FormatSARIF && slices.Contains(noFindings, opts.Command) {
+		return nil, fmt.Errorf("%s has no findings for the %s output", cmd.CommandPath(), format)
+	}
+	return xmreport.NewWriter(cmd.OutOrStdout(), format, opts)
+}
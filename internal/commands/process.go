@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+var addrFamilies = []string{
+	"AF_UNSPEC",
+	"AF_UNIX",
+	"AF_INET",
+	"AF_AX25",
+	"AF_IPX",
+	"AF_APPLETALK",
+	"AF_NETROM",
+	"AF_BRIDGE",
+	"AF_ATMPVC",
+	"AF_X25",
+	"AF_INET6",
+	"AF_ROSE",
+	"AF_DECnet",
+	"AF_NETBEUI",
+	"AF_SECURITY",
+	"AF_KEY",
+	"AF_NETLINK",
+	"AF_PACKET",
+	"AF_ASH",
+	"AF_ECONET",
+	"AF_ATMSVC",
+	"AF_RDS",
+	"AF_SNA",
+	"AF_IRDA",
+	"AF_PPPOX",
+	"AF_WANPIPE",
+	"AF_LLC",
+	"AF_IB",
+	"AF_MPLS",
+	"AF_CAN",
+	"AF_TIPC",
+	"AF_BLUETOOTH",
+	"AF_IUCV",
+	"AF_RXRPC",
+	"AF_ISDN",
+	"AF_PHONET",
+	"AF_IEEE802154",
+	"AF_CAIF",
+	"AF_ALG",
+	"AF_NFC",
+	"AF_VSOCK",
+	"AF_KCM",
+	"AF_QIPCRTR",
+	"AF_SMC",
+	"AF_XDP",
+	"AF_MCTP",
+}
+
+func newProcCmd() *cobra.Command {
+	var showDeps, showBuildSettings, showConn, showVulns bool
+	var filter string
+	var table tableFlags
+
+	c := &cobra.Command{
+		Use:     "process [<pid>]",
+		Aliases: []string{"proc", "ps", "p"},
+		Short:   "Examine currently running Go processes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var all []*process.Process
+			var err error
+			if len(args) == 0 {
+				all, err = process.Processes()
+				if err != nil {
+					return err
+				}
+			} else {
+				pid, err := strconv.Atoi(args[0])
+				if err != nil {
+					return err
+				}
+				pr, err := process.NewProcess(int32(pid))
+				if err != nil {
+					return err
+				}
+				all = []*process.Process{pr}
+				filter = ""
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{
+				Command:   "process",
+				ShowDeps:  showDeps,
+				ShowBuild: showBuildSettings,
+				ShowConn:  showConn,
+				ShowVulns: showVulns,
+				Table:     table.options(),
+			})
+			if err != nil {
+				return err
+			}
+			db, err := vulnDBIf(cmd, showVulns)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			for _, p := range all {
+				pr := readProcess(p, showConn)
+				if pr == nil {
+					continue
+				}
+				if filter != "" && !strings.Contains(pr.Main.Path, filter) {
+					continue
+				}
+				if db != nil {
+					if err := checkVulns(db, pr); err != nil {
+						return err
+					}
+				}
+				if err := w.Write(pr); err != nil {
+					return err
+				}
+			}
+			return w.Close()
+		},
+	}
+
+	c.Flags().BoolVarP(
+		&showDeps, "deps", "d", false, "show all the dependency modules",
+	)
+	c.Flags().BoolVarP(
+		&showBuildSettings, "build", "b", false, "show the build settings used to build the binary",
+	)
+	c.Flags().BoolVar(
+		&showConn, "conn", false, "show all the connections (TCP, UDP, Unix) used by the process",
+	)
+	c.Flags().StringVar(
+		&filter, "filter", "", "filter by the package name",
+	)
+	addVulnFlag(c, &showVulns)
+	addTableFlags(c, &table)
+
+	return c
+}
+
+// readProcess examines the executable of the process, or returns nil if it is not a Go binary.
+func readProcess(p *process.Process, showConn bool) *xmreport.Process {
+	path, err := p.Exe()
+	if err != nil {
+		return nil
+	}
+	src, err := xmsource.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer src.Close()
+	b, err := xmsource.ReadBinary(src, xmsource.Options{})
+	if err != nil {
+		return nil
+	}
+
+	name, err := p.Name()
+	if err != nil {
+		name = err.Error()
+	}
+
+	pr := &xmreport.Process{
+		PID:    p.Pid,
+		Name:   name,
+		Binary: b,
+	}
+
+	if showConn {
+		conns, err := p.Connections()
+		if err != nil {
+			pr.ConnError = err.Error()
+		}
+		for _, c := range conns {
+			family := strconv.FormatUint(uint64(c.Family), 10)
+			if int(c.Family) < len(addrFamilies) {
+				family = addrFamilies[c.Family]
+			}
+			pr.Connections = append(pr.Connections, xmreport.Connection{
+				Family: family,
+				Local:  xmreport.Addr{IP: c.Laddr.IP, Port: c.Laddr.Port},
+				Remote: xmreport.Addr{IP: c.Raddr.IP, Port: c.Raddr.Port},
+				Status: c.Status,
+			})
+		}
+	}
+	return pr
+}
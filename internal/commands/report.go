@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmpage"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+var errNoReportFile = errors.New("either --html or --markdown is required")
+
+func newReportCmd() *cobra.Command {
+	var htmlFile, markdownFile string
+	var showLatest bool
+	var inventory inventoryFlags
+
+	c := &cobra.Command{
+		Use:   "report",
+		Short: "Render the inventory of Go binaries, processes and modules as an HTML or Markdown page",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if htmlFile == "" && markdownFile == "" {
+				return errNoReportFile
+			}
+			if err := checkOutputUnused(cmd, cmd.CommandPath()); err != nil {
+				return err
+			}
+
+			r, err := readInventory(cmd, "report", &inventory)
+			if err != nil {
+				return err
+			}
+			if showLatest {
+				lookupInventory(r, getJobs(cmd))
+			}
+
+			if htmlFile != "" {
+				if err := writeOutput(htmlFile, func(w io.Writer) error { return xmpage.HTML(w, r) }); err != nil {
+					return err
+				}
+			}
+			if markdownFile != "" {
+				if err := writeOutput(markdownFile, func(w io.Writer) error { return xmpage.Markdown(w, r) }); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	c.Flags().StringVar(
+		&htmlFile, "html", "", "write the HTML report to the file (\"-\" for stdout)",
+	)
+	c.Flags().StringVar(
+		&markdownFile, "markdown", "", "write the Markdown report to the file (\"-\" for stdout)",
+	)
+	c.Flags().BoolVarP(
+		&showLatest, "latest", "l", false, "look up the latest versions of the dependencies to count the outdated ones",
+	)
+	addInventoryFlags(c, &inventory)
+
+	return c
+}
+
+// lookupInventory looks up the latest versions of all the modules of the inventory. The
+// pages show no major upgrades, deprecations and retractions, so they are not looked up.
+func lookupInventory(r *xmreport.Report, jobs int) {
+	var mods []*xmreport.Module
+	for _, b := range r.Binaries {
+		mods = append(mods, b.Deps...)
+	}
+	for _, p := range r.Processes {
+		mods = append(mods, p.Deps...)
+	}
+	for _, m := range r.Modules {
+		for _, req := range m.Requires {
+			mods = append(mods, req.Module)
+		}
+	}
+	lookupModules(mods, false, jobs)
+}
+
+// writeOutput writes the output rendered by render to the file, or to stdout for "-".
+func writeOutput(name string, render func(io.Writer) error) error {
+	if name == "-" {
+		return render(os.Stdout)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := render(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
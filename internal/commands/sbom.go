@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/build"
+	"github.com/o7q2ab/goxm/internal/xmsbom"
+)
+
+var errNoTargets = errors.New("no Go binary files were found")
+
+func newSBOMCmd() *cobra.Command {
+	var spec string
+	var walk walkFlags
+
+	c := &cobra.Command{
+		Use:   "sbom " + targetsUsage + "...",
+		Short: "Generate a software bill of materials of Go binaries, processes and modules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkOutputUnused(cmd, cmd.CommandPath()); err != nil {
+				return err
+			}
+			paths, err := walk.paths(cmd, args)
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				return errNoPaths
+			}
+			walkOpts, err := walk.options()
+			if err != nil {
+				return err
+			}
+			w, err := xmsbom.NewWriter(cmd.OutOrStdout(), spec, xmsbom.Tool{Name: "goxm", Version: build.Version()})
+			if err != nil {
+				return err
+			}
+			n := 0
+			for _, arg := range paths {
+				targets, err := loadTargets(arg, walkOpts, getJobs(cmd))
+				if err != nil {
+					return err
+				}
+				for _, t := range targets {
+					if err := w.Write(t); err != nil {
+						return err
+					}
+					n++
+				}
+			}
+			if n == 0 {
+				return errNoTargets
+			}
+			return w.Close()
+		},
+	}
+
+	c.Flags().StringVar(
+		&spec, "spec", xmsbom.FormatCycloneDX, "SBOM specification: "+strings.Join(xmsbom.Formats, ", "),
+	)
+	addWalkFlags(c, &walk)
+
+	return c
+}
@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmarchive"
+	"github.com/o7q2ab/goxm/internal/xmmod"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+type scanOptions struct {
+	showDeps, showLatest, showBuildSettings bool
+	// lookupMain looks up the latest versions of the main modules.
+	lookupMain bool
+	// lookupNotices looks up the major upgrades, the deprecations and the retractions
+	// of the modules too, for the outputs showing them.
+	lookupNotices bool
+	jobs          int
+	// vulndb, if set, is checked for the vulnerabilities of the binaries.
+	vulndb *xmvuln.DB
+}
+
+func addJobsFlag(c *cobra.Command) {
+	c.PersistentFlags().IntP(
+		"jobs", "j", runtime.NumCPU(), "number of files read and module versions looked up concurrently",
+	)
+}
+
+func getJobs(cmd *cobra.Command) int {
+	jobs, err := cmd.Flags().GetInt("jobs")
+	if err != nil || jobs < 1 {
+		return 1
+	}
+	return jobs
+}
+
+// readFile returns the Go binary file, or the Go binaries in the archive. The name "-"
+// stands for the standard input. It is used for the files named explicitly, the ones
+// found by scanning directories are read by readBinaries.
+func readFile(name string, opts xmsource.Options) ([]*xmreport.Binary, error) {
+	src, err := xmsource.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	if xmarchive.IsArchive(src) {
+		return xmarchive.Scan(src, opts)
+	}
+	b, err := xmsource.ReadBinary(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	return []*xmreport.Binary{b}, nil
+}
+
+func fromArchive(b *xmreport.Binary) bool {
+	return b.Archive != ""
+}
+
+// readBinaries reads the files concurrently and returns the Go binaries found
+// among them in the order of names. The files which cannot be read are skipped,
+// and so are the archives: only the leading bytes of the files which are not
+// executables are read.
+func readBinaries(names []string, opts xmsource.Options, jobs int) []*xmreport.Binary {
+	p := newProgress("Reading files", len(names))
+	defer p.done()
+
+	bins := make([]*xmreport.Binary, len(names))
+	parallel(len(names), jobs, func(i int) {
+		defer p.add()
+		bins[i], _ = readBinary(names[i], opts)
+	})
+	return slices.DeleteFunc(bins, func(b *xmreport.Binary) bool { return b == nil })
+}
+
+func readBinary(name string, opts xmsource.Options) (*xmreport.Binary, error) {
+	src, err := xmsource.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return xmsource.ReadBinary(src, opts)
+}
+
+// lookupModules looks up the latest versions of the modules concurrently,
+// resolving every unique module path only once. With notices, it also probes the
+// higher major versions and reads the retractions and the deprecations.
+func lookupModules(mods []*xmreport.Module, notices bool, jobs int) {
+	byPath := map[string][]*xmreport.Module{}
+	for _, m := range mods {
+		// The main modules of the Go distribution commands have no paths.
+		if m.Path != "" {
+			byPath[m.Path] = append(byPath[m.Path], m)
+		}
+	}
+	paths := slices.Sorted(maps.Keys(byPath))
+
+	p := newProgress("Looking up modules", len(paths))
+	defer p.done()
+
+	parallel(len(paths), jobs, func(i int) {
+		defer p.add()
+		current := ""
+		for _, m := range byPath[paths[i]] {
+			if semver.Build(m.Version) == "+incompatible" {
+				current = m.Version
+			}
+		}
+		latest := getLatest(paths[i], current)
+		var major *xmreport.Latest
+		var status *xmmod.Status
+		if notices {
+			major = getMajor(paths[i])
+			status = getStatus(paths[i], current)
+		}
+		times := map[string]*time.Time{}
+		for _, m := range byPath[paths[i]] {
+			m.Latest = latest
+			m.Major = major
+			if status != nil {
+				m.Deprecated = status.Deprecated
+				if r := status.Retracted(m.Version); r != nil {
+					m.Retracted = &xmreport.Retraction{Rationale: r.Rationale}
+				}
+			}
+			if _, ok := times[m.Version]; !ok {
+				times[m.Version] = getTime(m.Path, m.Version)
+			}
+			m.Time = times[m.Version]
+			if m.Time == nil && m.Commit != nil {
+				m.Time = &m.Commit.Time
+			}
+			describeUpdate(m)
+		}
+	})
+}
+
+// describeUpdate classifies the available update of the module and tells how far behind it is.
+func describeUpdate(m *xmreport.Module) {
+	if m.Latest != nil {
+		m.Update = xmmod.ClassifyUpdate(m.Version, m.Latest.Version)
+	}
+	if c := m.Commit; c != nil && m.Latest != nil && m.Latest.Time != nil && !module.IsPseudoVersion(m.Latest.Version) {
+		// A pseudo-version may be newer than the tags above its base, which only
+		// the time of the commit can tell. A "(devel)" build has nothing but the time.
+		newer := m.Latest.Time.After(c.Time)
+		if newer && (!semver.IsValid(m.Version) || semver.Compare(m.Latest.Version, m.Version) > 0) {
+			m.NewerRelease = m.Latest.Version
+		} else if !newer && m.Update == xmmod.UpdatePseudo {
+			m.Update = ""
+		}
+	}
+	if m.Update != "" && m.Time != nil && m.Latest != nil && m.Latest.Time != nil && m.Latest.Time.After(*m.Time) {
+		m.DaysBehind = int(m.Latest.Time.Sub(*m.Time).Hours() / 24)
+	}
+}
+
+func getLatest(modpath, current string) *xmreport.Latest {
+	r, err := xmmod.DefaultResolver()
+	if err != nil {
+		return &xmreport.Latest{Error: err.Error()}
+	}
+	l := &xmreport.Latest{Offline: r.Offline()}
+	if l.Version, err = r.Latest(modpath, current); err != nil {
+		l.Error = err.Error()
+		return l
+	}
+	l.Time = getTime(modpath, l.Version)
+	return l
+}
+
+// getTime returns when the module version was published, or nil if it is unknown.
+func getTime(modpath, version string) *time.Time {
+	r, err := xmmod.DefaultResolver()
+	if err != nil || !semver.IsValid(version) {
+		return nil
+	}
+	info, err := r.Info(modpath, version)
+	if err != nil || info.Time.IsZero() {
+		return nil
+	}
+	return &info.Time
+}
+
+// getStatus returns the deprecation and the retractions of the module, or nil if they are unknown.
+func getStatus(modpath, current string) *xmmod.Status {
+	r, err := xmmod.DefaultResolver()
+	if err != nil {
+		return nil
+	}
+	s, err := r.Status(modpath, current)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// getMajor returns the latest major version upgrade of the module, or nil if there is none.
+func getMajor(modpath string) *xmreport.Latest {
+	r, err := xmmod.DefaultResolver()
+	if err != nil {
+		return nil
+	}
+	path, version, err := r.LatestMajor(modpath)
+	if err != nil || path == "" {
+		return nil
+	}
+	return &xmreport.Latest{Path: path, Version: version, Offline: r.Offline()}
+}
+
+// parallel calls fn for every index in [0, n) using at most jobs goroutines.
+func parallel(n, jobs int, fn func(i int)) {
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for range min(n, jobs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+}
+
+// progress reports the number of completed steps on stderr, if it is a terminal.
+type progress struct {
+	mu    sync.Mutex
+	title string
+	total int
+	count int
+}
+
+func newProgress(title string, total int) *progress {
+	if total < 2 || !isTerminal(os.Stderr) {
+		return nil
+	}
+	p := &progress{title: title, total: total}
+	p.print()
+	return p
+}
+
+func (p *progress) add() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count++
+	p.print()
+}
+
+func (p *progress) done() {
+	if p == nil {
+		return
+	}
+	// Erase the line, so that it does not mix with the output.
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+func (p *progress) print() {
+	fmt.Fprintf(os.Stderr, "\r%s: %d/%d", p.title, p.count, p.total)
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
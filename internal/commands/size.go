@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+	"github.com/o7q2ab/goxm/internal/xmsym"
+)
+
+func newSizeCmd() *cobra.Command {
+	var top int
+	var treemapFile string
+
+	c := &cobra.Command{
+		Use:   "size <file-path> | -",
+		Short: "Attribute the size of a Go binary to its modules and packages",
+		Long: `Attribute the size of a Go binary to its modules and packages.
+The bytes of the text, rodata and data sections are attributed by the symbol table,
+or by the functions in the pclntab if the binary is stripped.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if treemapFile == xmsource.Stdin {
+				if err := checkOutputUnused(cmd, "--treemap -"); err != nil {
+					return err
+				}
+			}
+			src, err := xmsource.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+
+			b, err := xmsource.ReadBinary(src, xmsource.Options{})
+			if err != nil {
+				return err
+			}
+			l, err := xmsym.ReadLayout(src)
+			if err != nil {
+				return err
+			}
+			s := xmreport.NewSize(b, l)
+
+			if treemapFile != "" {
+				err := writeOutput(treemapFile, func(w io.Writer) error {
+					enc := json.NewEncoder(w)
+					enc.SetIndent("", "  ")
+					return enc.Encode(s.Tree())
+				})
+				// The treemap replaces the output on stdout.
+				if err != nil || treemapFile == xmsource.Stdin {
+					return err
+				}
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{Command: "size", Short: true, Top: top})
+			if err != nil {
+				return err
+			}
+			if err := w.Write(s); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+
+	c.Flags().IntVarP(
+		&top, "top", "n", 20, "number of the largest modules and packages shown, 0 shows all",
+	)
+	c.Flags().StringVar(
+		&treemapFile, "treemap", "", "write the sizes as a tree of modules and packages in JSON for treemap charts to the file (\"-\" for stdout)",
+	)
+
+	return c
+}
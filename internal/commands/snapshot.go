@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmmod"
+	"github.com/o7q2ab/goxm/internal/xmpath"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+func newSnapshotCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Record the inventory of Go binaries, processes and modules and compare it over time",
+	}
+	c.AddCommand(
+		newSnapshotSaveCmd(),
+		newSnapshotDiffCmd(),
+	)
+	return c
+}
+
+// inventoryFlags select what is recorded in the inventory of a host.
+type inventoryFlags struct {
+	dirs, modDirs       []string
+	noPath, noProcesses bool
+}
+
+func addInventoryFlags(c *cobra.Command, f *inventoryFlags) {
+	c.Flags().StringSliceVar(
+		&f.dirs, "dir", nil, "also examine the binary files in the directory (can be repeated)",
+	)
+	c.Flags().StringSliceVar(
+		&f.modDirs, "modules", nil, "also record the go.mod files found in the directory (can be repeated)",
+	)
+	c.Flags().BoolVar(
+		&f.noPath, "no-path", false, "do not examine the binaries found in directories added to PATH",
+	)
+	c.Flags().BoolVar(
+		&f.noProcesses, "no-processes", false, "do not examine the running Go processes",
+	)
+}
+
+// readInventory examines the binaries, processes and go.mod files of the host.
+func readInventory(cmd *cobra.Command, command string, f *inventoryFlags) (*xmreport.Report, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	host, _ := os.Hostname()
+	r := &xmreport.Report{
+		Schema:  xmreport.Schema,
+		Command: command,
+		Created: &now,
+		Host:    host,
+	}
+
+	var names []string
+	if !f.noPath {
+		names = xmpath.ListPathEnv()
+	}
+	for _, d := range f.dirs {
+		names = append(names, xmpath.List(d)...)
+	}
+	slices.Sort(names)
+	r.Binaries = readBinaries(slices.Compact(names), xmsource.Options{}, getJobs(cmd))
+
+	if !f.noProcesses {
+		all, err := process.Processes()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range all {
+			if pr := readProcess(p, false); pr != nil {
+				r.Processes = append(r.Processes, pr)
+			}
+		}
+	}
+
+	for _, d := range f.modDirs {
+		found, err := findModules(cmd, d)
+		if err != nil {
+			return nil, err
+		}
+		for _, one := range found {
+			modf, err := xmmod.Read(one)
+			if err != nil {
+				r.Modules = append(r.Modules, &xmreport.GoMod{File: one, Error: err.Error()})
+				continue
+			}
+			r.Modules = append(r.Modules, xmreport.NewGoMod(one, modf))
+		}
+	}
+	return r, nil
+}
+
+func newSnapshotSaveCmd() *cobra.Command {
+	var inventory inventoryFlags
+
+	c := &cobra.Command{
+		Use:   "save [<file-path>]",
+		Short: "Save the inventory to a JSON file (or print it)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkOutputUnused(cmd, cmd.CommandPath()); err != nil {
+				return err
+			}
+			r, err := readInventory(cmd, "snapshot save", &inventory)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(args) != 0 {
+				f, err := os.Create(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		},
+	}
+
+	addInventoryFlags(c, &inventory)
+
+	return c
+}
+
+func newSnapshotDiffCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff <old-file> <new-file>",
+		Short: "Show what changed between two saved inventories",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			old, err := readSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+			new, err := readSnapshot(args[1])
+			if err != nil {
+				return err
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{Command: "snapshot diff"})
+			if err != nil {
+				return err
+			}
+			if err := w.Write(xmreport.CompareReports(args[0], old, args[1], new)); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+	return c
+}
+
+func readSnapshot(name string) (*xmreport.Report, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return xmreport.ReadReport(f)
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+type tableFlags struct {
+	table   bool
+	columns []string
+	sort    string
+	groupBy string
+}
+
+func addTableFlags(c *cobra.Command, f *tableFlags) {
+	c.Flags().BoolVar(
+		&f.table, "table", false, "print one aligned row per target",
+	)
+	c.Flags().StringSliceVar(
+		&f.columns, "columns", nil,
+		fmt.Sprintf("table columns, any of: %s (implies --table)", strings.Join(xmreport.TableColumns, ", ")),
+	)
+	c.Flags().StringVar(
+		&f.sort, "sort", "", "sort the table by the column, prefix it with - for descending order (implies --table)",
+	)
+	c.Flags().StringVar(
+		&f.groupBy, "group-by", "",
+		fmt.Sprintf("group the table rows by: %s (implies --table)", strings.Join(xmreport.TableGroups, ", ")),
+	)
+}
+
+// options returns the table view options, or nil if the table is not requested.
+func (f *tableFlags) options() *xmreport.Table {
+	if !f.table && len(f.columns) == 0 && f.sort == "" && f.groupBy == "" {
+		return nil
+	}
+	return &xmreport.Table{Columns: f.columns, Sort: f.sort, GroupBy: f.groupBy}
+}
+
+// needsLatest reports whether the table shows or is sorted by the latest versions.
+func (f *tableFlags) needsLatest() bool {
+	return slices.Contains(f.columns, "latest") || strings.TrimPrefix(f.sort, "-") == "latest"
+}
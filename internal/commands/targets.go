@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/shirou/gopsutil/v4/process"
+
+	"github.com/o7q2ab/goxm/internal/xmmod"
+	"github.com/o7q2ab/goxm/internal/xmpath"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+// targetsUsage describes the arguments accepted by loadTargets.
+const targetsUsage = "[<file-path> | <dir-path> | <go.mod> | <pid> | PATH | -]"
+
+// loadTargets examines what the argument names: "PATH" for the Go binaries found in
+// the PATH directories, a go.mod file, the ID of a running Go process, or a binary file
+// or an archive, or a directory of them, listed as configured by walk. "-" stands for
+// a binary or an archive read from the standard input.
+func loadTargets(arg string, walk *xmpath.Options, jobs int) ([]xmreport.Target, error) {
+	switch {
+	case arg == "PATH":
+		return binaryTargets(readBinaries(xmpath.ListPathEnv(), xmsource.Options{}, jobs)), nil
+	case arg == xmsource.Stdin:
+		bins, err := readFile(arg, xmsource.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return binaryTargets(bins), nil
+	case filepath.Base(arg) == "go.mod":
+		modf, err := xmmod.Read(arg)
+		if err != nil {
+			return nil, err
+		}
+		return []xmreport.Target{xmreport.NewGoMod(arg, modf)}, nil
+	}
+
+	stat, err := os.Stat(arg)
+	if err != nil {
+		pid, perr := strconv.ParseInt(arg, 10, 32)
+		if perr != nil {
+			return nil, err
+		}
+		p, err := process.NewProcess(int32(pid))
+		if err != nil {
+			return nil, err
+		}
+		pr := readProcess(p, false)
+		if pr == nil {
+			return nil, fmt.Errorf("process %d is not a Go program", pid)
+		}
+		return []xmreport.Target{pr}, nil
+	}
+
+	if !stat.IsDir() {
+		bins, err := readFile(arg, xmsource.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return binaryTargets(bins), nil
+	}
+	return binaryTargets(readBinaries(xmpath.Walk(arg, walk), xmsource.Options{}, jobs)), nil
+}
+
+func binaryTargets(bins []*xmreport.Binary) []xmreport.Target {
+	targets := make([]xmreport.Target, 0, len(bins))
+	for _, b := range bins {
+		targets = append(targets, b)
+	}
+	return targets
+}
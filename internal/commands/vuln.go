@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+var errNoVulnDB = errors.New("no vulnerability database: use --vulndb or set GOVULNDB to a local path")
+
+func addVulnDBFlag(c *cobra.Command) {
+	c.PersistentFlags().String(
+		"vulndb", "", "directory or zip file with an OSV database in the vuln.go.dev layout (default: $GOVULNDB if it is a local path)",
+	)
+}
+
+func addVulnFlag(c *cobra.Command, showVulns *bool) {
+	c.Flags().BoolVar(
+		showVulns, "vuln", false, "check the modules for known vulnerabilities, see --vulndb",
+	)
+}
+
+// vulnDBIf opens the vulnerability database if it is needed.
+func vulnDBIf(cmd *cobra.Command, needed bool) (*xmvuln.DB, error) {
+	if !needed {
+		return nil, nil
+	}
+	return openVulnDB(cmd)
+}
+
+func openVulnDB(cmd *cobra.Command) (*xmvuln.DB, error) {
+	name, err := cmd.Flags().GetString("vulndb")
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		env := os.Getenv("GOVULNDB")
+		if env == "" || (strings.Contains(env, "://") && !strings.HasPrefix(env, "file://")) {
+			return nil, errNoVulnDB
+		}
+		name = env
+	}
+	return xmvuln.Open(name)
+}
+
+func newVulnCmd() *cobra.Command {
+	var walk walkFlags
+
+	c := &cobra.Command{
+		Use:   "vuln " + targetsUsage + "...",
+		Short: "Check Go binaries, processes and modules for known vulnerabilities",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openVulnDB(cmd)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			paths, err := walk.paths(cmd, args)
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				paths = []string{"PATH"}
+			}
+			walkOpts, err := walk.options()
+			if err != nil {
+				return err
+			}
+
+			w, err := newWriter(cmd, xmreport.Options{Command: "vuln", ShowVulns: true})
+			if err != nil {
+				return err
+			}
+			for _, arg := range paths {
+				targets, err := loadTargets(arg, walkOpts, getJobs(cmd))
+				if err != nil {
+					return err
+				}
+				for _, t := range targets {
+					if err := checkVulns(db, t); err != nil {
+						return err
+					}
+					if err := w.Write(t); err != nil {
+						return err
+					}
+				}
+			}
+			return w.Close()
+		},
+	}
+	addWalkFlags(c, &walk)
+	return c
+}
+
+// checkVulns records the known vulnerabilities of the modules the target consists of.
+func checkVulns(db *xmvuln.DB, t xmreport.Target) error {
+	switch t := t.(type) {
+	case *xmreport.Binary:
+		if v := xmvuln.GoVersion(t.GoVersion); v != "" {
+			t.Stdlib = &xmreport.Module{Path: xmvuln.Stdlib, Version: v}
+			if err := moduleVulns(db, t.Stdlib); err != nil {
+				return err
+			}
+		}
+		if err := moduleVulns(db, t.Main); err != nil {
+			return err
+		}
+		for _, d := range t.Deps {
+			if err := moduleVulns(db, d); err != nil {
+				return err
+			}
+		}
+	case *xmreport.Process:
+		return checkVulns(db, t.Binary)
+	case *xmreport.GoMod:
+		for _, r := range t.Requires {
+			if err := moduleVulns(db, r.Module); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func moduleVulns(db *xmvuln.DB, m *xmreport.Module) error {
+	path, version := m.Path, m.Version
+	if m.Replace != nil {
+		path, version = m.Replace.Path, m.Replace.Version
+	}
+	if !semver.IsValid(version) {
+		return nil
+	}
+	entries, err := db.Vulns(path, version)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		m.Vulns = append(m.Vulns, &xmreport.Vuln{
+			ID:      e.ID,
+			Aliases: e.Aliases,
+			Summary: e.Summary,
+			Fixed:   e.Fixed(path, version),
+			URL:     e.URL(),
+		})
+	}
+	return nil
+}
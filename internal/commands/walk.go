@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/o7q2ab/goxm/internal/xmpath"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+var (
+	errNoPaths     = errors.New("no paths were given as the arguments or with --files-from")
+	errStdinListed = errors.New("the standard input cannot be both examined and read with --files-from")
+)
+
+// walkFlags control how the directories given as arguments are scanned.
+type walkFlags struct {
+	recursive, followSymlinks bool
+	maxDepth                  int
+	include, exclude          []string
+	filesFrom                 string
+}
+
+func addWalkFlags(c *cobra.Command, f *walkFlags) {
+	c.Flags().BoolVarP(
+		&f.recursive, "recursive", "r", false, "examine the files in the subdirectories too",
+	)
+	c.Flags().IntVar(
+		&f.maxDepth, "max-depth", 0, "maximum depth of the subdirectories examined, 1 is the directory itself (implies --recursive)",
+	)
+	c.Flags().StringSliceVar(
+		&f.include, "include", nil, "examine only the files whose names or relative paths match the glob (can be repeated)",
+	)
+	c.Flags().StringSliceVar(
+		&f.exclude, "exclude", nil, "skip the files and directories whose names or relative paths match the glob (can be repeated)",
+	)
+	c.Flags().BoolVar(
+		&f.followSymlinks, "follow-symlinks", false, "descend into symbolic links to directories",
+	)
+	c.Flags().StringVar(
+		&f.filesFrom, "files-from", "", "also examine the paths listed in the file, one per line (\"-\" for stdin)",
+	)
+}
+
+func (f *walkFlags) options() (*xmpath.Options, error) {
+	opts := &xmpath.Options{
+		Recursive:      f.recursive || f.maxDepth > 0,
+		MaxDepth:       f.maxDepth,
+		Include:        f.include,
+		Exclude:        f.exclude,
+		FollowSymlinks: f.followSymlinks,
+	}
+	return opts, opts.Validate()
+}
+
+// paths returns the arguments followed by the paths listed in the --files-from file.
+func (f *walkFlags) paths(cmd *cobra.Command, args []string) ([]string, error) {
+	listed, err := f.listed(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(args), listed...), nil
+}
+
+// listed returns the paths listed in the --files-from file.
+func (f *walkFlags) listed(cmd *cobra.Command, args []string) ([]string, error) {
+	if f.filesFrom == "" {
+		return nil, nil
+	}
+	if f.filesFrom == xmsource.Stdin && slices.Contains(args, xmsource.Stdin) {
+		return nil, errStdinListed
+	}
+	var r io.Reader = cmd.InOrStdin()
+	if f.filesFrom != xmsource.Stdin {
+		file, err := os.Open(f.filesFrom)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var paths []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if p := strings.TrimSpace(s.Text()); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, s.Err()
+}
+
+// uniqueNames removes the repeated names, keeping the first ones.
+func uniqueNames(names []string) []string {
+	seen := map[string]bool{}
+	return slices.DeleteFunc(names, func(n string) bool {
+		if seen[n] {
+			return true
+		}
+		seen[n] = true
+		return false
+	})
+}
@@ -0,0 +1,206 @@
+package xmarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+// Formats of the archives, detected by their leading bytes.
+const (
+	FormatTar   = "tar"
+	FormatGzip  = "gzip"
+	FormatBzip2 = "bzip2"
+	FormatXz    = "xz"
+	FormatZstd  = "zstd"
+	FormatZip   = "zip"
+	// FormatAr is the container of the Debian packages.
+	FormatAr = "ar"
+)
+
+var (
+	errUnsupported = errors.New("unsupported compression, only gzip, bzip2, xz and zstd are supported")
+	errNoDebData   = errors.New("no data.tar member found in the Debian package")
+)
+
+// headSize is the number of leading bytes needed to detect the formats, the tar
+// magic is at offset 257.
+const headSize = 512
+
+// Detect returns the format of the archive by its leading bytes, or "" if it is not one.
+func Detect(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return FormatGzip
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return FormatBzip2
+	case bytes.HasPrefix(head, []byte("\xfd7zXZ\x00")):
+		return FormatXz
+	case bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return FormatZstd
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return FormatZip
+	case bytes.HasPrefix(head, []byte("!<arch>\n")):
+		return FormatAr
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return FormatTar
+	}
+	return ""
+}
+
+// IsArchive reports whether the source is an archive of one of the supported formats.
+func IsArchive(src *xmsource.Source) bool {
+	return Detect(src.Head(headSize)) != ""
+}
+
+// Scan returns the Go binaries in the archive, reported by their paths in it.
+// The entries are streamed and the build info is read from memory, nothing is
+// extracted to the disk. Compressed files which are not tar archives are treated
+// as a single entry named like the archive without its extension.
+func Scan(src *xmsource.Source, opts xmsource.Options) ([]*xmreport.Binary, error) {
+	s := &scanner{archive: src.Name, opts: opts}
+	var err error
+	if Detect(src.Head(headSize)) == FormatZip {
+		err = s.zip(src, src.Size)
+	} else {
+		base := filepath.Base(src.Name)
+		err = s.stream(bufio.NewReaderSize(src.Reader(), headSize), strings.TrimSuffix(base, filepath.Ext(base)), src.ModTime)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src.Name, err)
+	}
+	return s.bins, nil
+}
+
+type scanner struct {
+	archive string
+	opts    xmsource.Options
+	bins    []*xmreport.Binary
+}
+
+// stream scans the tar, ar or compressed stream. The name and mtime describe
+// the stream itself, if it turns out to be a single binary.
+func (s *scanner) stream(r *bufio.Reader, name string, mtime time.Time) error {
+	head, _ := r.Peek(headSize)
+	switch Detect(head) {
+	case FormatTar:
+		return s.tar(r)
+	case FormatAr:
+		return s.deb(r)
+	case FormatGzip, FormatBzip2, FormatXz, FormatZstd:
+		rc, err := decompress(r, Detect(head))
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return s.stream(bufio.NewReaderSize(rc, headSize), name, mtime)
+	}
+	return s.entry(name, r, mtime)
+}
+
+// Decompress returns the stream without its gzip, bzip2, xz or zstd compression,
+// or the stream itself if it is not compressed.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(r, headSize)
+	head, _ := br.Peek(headSize)
+	switch format := Detect(head); format {
+	case FormatGzip, FormatBzip2, FormatXz, FormatZstd:
+		return decompress(br, format)
+	}
+	return io.NopCloser(br), nil
+}
+
+func decompress(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case FormatGzip:
+		return gzip.NewReader(r)
+	case FormatBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case FormatXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case FormatZstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, errUnsupported
+}
+
+func (s *scanner) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := s.entry(CleanPath(hdr.Name), tr, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *scanner) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = s.entry(CleanPath(f.Name), rc, f.Modified)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entry reads the build info of the entry if it is a Go binary.
+func (s *scanner) entry(name string, r io.Reader, mtime time.Time) error {
+	b, err := xmsource.ReadStream(name, r, mtime, s.opts)
+	if b != nil {
+		b.Archive = s.archive
+		s.bins = append(s.bins, b)
+	}
+	return err
+}
+
+// CleanPath returns the slash-separated path of the entry relative to the archive root,
+// e.g. "usr/bin/app" for "./usr/bin/app".
+func CleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,56 @@
+package xmarchive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// arHeaderSize is the size of the member headers of the ar archives, see ar(5).
+const arHeaderSize = 60
+
+// deb scans the data.tar member of the Debian package, the installed files.
+// The package is an ar archive of debian-binary, control.tar and data.tar members.
+// The other ar archives, like the static libraries, have no Go binaries.
+func (s *scanner) deb(r *bufio.Reader) error {
+	if _, err := r.Discard(len("!<arch>\n")); err != nil {
+		return err
+	}
+	var hdr [arHeaderSize]byte
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF && i == 0 {
+				return nil
+			}
+			if err == io.EOF {
+				return errNoDebData
+			}
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimSpace(string(hdr[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || string(hdr[58:60]) != "`\n" {
+			return fmt.Errorf("invalid ar member header %q", hdr[:])
+		}
+		if i == 0 && name != "debian-binary" {
+			return nil
+		}
+
+		if strings.HasPrefix(name, "data.tar") {
+			data := bufio.NewReaderSize(io.LimitReader(r, size), headSize)
+			head, _ := data.Peek(headSize)
+			switch Detect(head) {
+			case FormatTar, FormatGzip, FormatBzip2, FormatXz, FormatZstd:
+				return s.stream(data, name, time.Time{})
+			}
+			return fmt.Errorf("%s: %w", name, errUnsupported)
+		}
+		// The members are aligned to even offsets.
+		if _, err := r.Discard(int(size + size%2)); err != nil {
+			return err
+		}
+	}
+}
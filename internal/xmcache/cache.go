@@ -0,0 +1,95 @@
+package xmcache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache is a directory of JSON values which expire after TTL.
+// A nil Cache or a Cache with zero TTL stores nothing.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Refresh makes Get miss, so that every value is fetched and stored again.
+	Refresh bool
+}
+
+type entry struct {
+	Time  time.Time       `json:"time"`
+	Value json.RawMessage `json:"value"`
+}
+
+// DefaultDir returns the goxm directory inside the user cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goxm"), nil
+}
+
+// Get loads the value stored under the slash-separated key into v.
+// It reports whether there was such a value younger than TTL.
+func (c *Cache) Get(key string, v any) bool {
+	if c == nil || c.TTL <= 0 || c.Refresh {
+		return false
+	}
+	data, err := os.ReadFile(c.file(key))
+	if err != nil {
+		return false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false
+	}
+	if time.Since(e.Time) > c.TTL {
+		return false
+	}
+	return json.Unmarshal(e.Value, v) == nil
+}
+
+// Put stores v under the slash-separated key.
+func (c *Cache) Put(key string, v any) error {
+	if c == nil || c.TTL <= 0 {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Time: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+
+	name := c.file(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that concurrent readers never see partial values.
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// Clean removes all the stored values.
+func (c *Cache) Clean() error {
+	return os.RemoveAll(c.Dir)
+}
+
+func (c *Cache) file(key string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(key)) + ".json"
+}
@@ -0,0 +1,97 @@
+package xmenv
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var defaults = map[string]string{
+	"GOPROXY": "https://proxy.golang.org,direct",
+}
+
+var (
+	fileOnce sync.Once
+	fileVars map[string]string
+)
+
+// Get returns the value of the Go environment variable key the same way
+// the go command resolves it: the process environment first, then the file
+// written by "go env -w", then the built-in default.
+func Get(key string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	fileOnce.Do(readFile)
+	if v := fileVars[key]; v != "" {
+		return v
+	}
+	switch key {
+	case "GONOPROXY":
+		return Get("GOPRIVATE")
+	case "GOPATH":
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, "go")
+		}
+	case "GOMODCACHE":
+		gopath := filepath.SplitList(Get("GOPATH"))
+		if len(gopath) != 0 {
+			return filepath.Join(gopath[0], "pkg", "mod")
+		}
+	}
+	return defaults[key]
+}
+
+// Flag returns the value of the flag set in GOFLAGS, e.g. "mod" for "-mod=mod", and
+// whether it is set. The boolean flags without a value are "true".
+func Flag(name string) (string, bool) {
+	value, found := "", false
+	for _, f := range strings.Fields(Get("GOFLAGS")) {
+		if !strings.HasPrefix(f, "-") {
+			continue
+		}
+		k, v, ok := strings.Cut(strings.TrimPrefix(f[1:], "-"), "=")
+		if k != name {
+			continue
+		}
+		if !ok {
+			v = "true"
+		}
+		// The last one wins, like on the command line.
+		value, found = v, true
+	}
+	return value, found
+}
+
+func readFile() {
+	fileVars = map[string]string{}
+
+	name := os.Getenv("GOENV")
+	if name == "off" {
+		return
+	}
+	if name == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return
+		}
+		name = filepath.Join(dir, "go", "env")
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		fileVars[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+}
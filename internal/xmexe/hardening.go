@@ -0,0 +1,212 @@
+// Package xmexe inspects the executable files.
+package xmexe
+
+import (
+	"bytes"
+	"cmp"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+)
+
+var errUnknownFormat = errors.New("unknown executable format, only ELF, PE and Mach-O are supported")
+
+// Formats of the executables.
+const (
+	FormatELF   = "elf"
+	FormatPE    = "pe"
+	FormatMachO = "macho"
+)
+
+// Inspect reads the security properties of the executable from its headers. The
+// properties from the build settings are left to the caller.
+func Inspect(r io.ReaderAt) (*xmreport.Hardening, error) {
+	head := make([]byte, 4)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(head, []byte("\x7fELF")):
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return inspectELF(f), nil
+	case bytes.HasPrefix(head, []byte("MZ")):
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return inspectPE(f), nil
+	}
+	switch binary.LittleEndian.Uint32(head) {
+	case macho.Magic32, macho.Magic64, 0xcefaedfe, 0xcffaedfe:
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return inspectMachO(f), nil
+	case 0xbebafeca:
+		f, err := macho.NewFatFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return inspectFat(f), nil
+	}
+	return nil, errUnknownFormat
+}
+
+func inspectELF(f *elf.File) *xmreport.Hardening {
+	h := &xmreport.Hardening{
+		Format: FormatELF,
+		Symtab: f.SectionByType(elf.SHT_SYMTAB) != nil,
+		DWARF:  hasDWARF(func(name string) bool { return f.Section(name) != nil }),
+	}
+	for _, p := range f.Progs {
+		switch p.Type {
+		case elf.PT_INTERP:
+			data, err := io.ReadAll(p.Open())
+			if err == nil {
+				h.Interpreter = string(bytes.TrimRight(data, "\x00"))
+			}
+		case elf.PT_GNU_RELRO:
+			h.RELRO = true
+		case elf.PT_GNU_STACK:
+			h.NXStack = p.Flags&elf.PF_X == 0
+		}
+	}
+	h.Needed, _ = f.ImportedLibraries()
+
+	flags, _ := f.DynValue(elf.DT_FLAGS)
+	flags1, _ := f.DynValue(elf.DT_FLAGS_1)
+	bindNow, _ := f.DynValue(elf.DT_BIND_NOW)
+	h.BindNow = len(bindNow) != 0 ||
+		anyFlag(flags, uint64(elf.DF_BIND_NOW)) || anyFlag(flags1, uint64(elf.DF_1_NOW))
+	// A shared library is of the same type, but it has no loader.
+	h.PIE = f.Type == elf.ET_DYN && (h.Interpreter != "" || anyFlag(flags1, uint64(elf.DF_1_PIE)))
+	h.Static = h.Interpreter == "" && len(h.Needed) == 0
+	return h
+}
+
+func anyFlag(values []uint64, flag uint64) bool {
+	for _, v := range values {
+		if v&flag != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func inspectPE(f *pe.File) *xmreport.Hardening {
+	h := &xmreport.Hardening{
+		Format: FormatPE,
+		Symtab: len(f.Symbols) != 0,
+		DWARF:  hasDWARF(func(name string) bool { return f.Section(name) != nil }),
+	}
+	var dll uint16
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dll = oh.DllCharacteristics
+	case *pe.OptionalHeader64:
+		dll = oh.DllCharacteristics
+	}
+	h.PIE = dll&pe.IMAGE_DLLCHARACTERISTICS_DYNAMIC_BASE != 0 && f.Characteristics&pe.IMAGE_FILE_RELOCS_STRIPPED == 0
+	h.NXStack = dll&pe.IMAGE_DLLCHARACTERISTICS_NX_COMPAT != 0
+	// The libraries are only known from the imported symbols, named like "Sleep:kernel32.dll".
+	syms, _ := f.ImportedSymbols()
+	for _, sym := range syms {
+		if _, lib, ok := strings.Cut(sym, ":"); ok && !slices.Contains(h.Needed, lib) {
+			h.Needed = append(h.Needed, lib)
+		}
+	}
+	h.Static = len(h.Needed) == 0
+	return h
+}
+
+// loadDylinker is the load command naming the dynamic loader.
+const loadDylinker = 0xe
+
+func inspectMachO(f *macho.File) *xmreport.Hardening {
+	h := &xmreport.Hardening{
+		Format:  FormatMachO,
+		PIE:     f.Flags&macho.FlagPIE != 0,
+		NXStack: f.Flags&macho.FlagAllowStackExecution == 0,
+		Symtab:  f.Symtab != nil && len(f.Symtab.Syms) != 0,
+		DWARF:   hasDWARF(func(name string) bool { return f.Section(name) != nil }),
+	}
+	for _, l := range f.Loads {
+		raw := l.Raw()
+		if len(raw) < 12 || f.ByteOrder.Uint32(raw) != loadDylinker {
+			continue
+		}
+		// The command is followed by its size and the offset of the name in it.
+		if off := f.ByteOrder.Uint32(raw[8:]); int(off) < len(raw) {
+			name, _, _ := bytes.Cut(raw[off:], []byte{0})
+			h.Interpreter = string(name)
+		}
+	}
+	h.Needed, _ = f.ImportedLibraries()
+	h.Static = h.Interpreter == "" && len(h.Needed) == 0
+	return h
+}
+
+// inspectFat reads the properties of every architecture of the universal binary.
+// The binary has a property only if all the architectures have it, and it needs
+// the loaders and the libraries of all of them.
+func inspectFat(f *macho.FatFile) *xmreport.Hardening {
+	h := &xmreport.Hardening{Format: FormatMachO, PIE: true, NXStack: true, Symtab: true, DWARF: true, Static: true}
+	for _, a := range f.Arches {
+		ah := inspectMachO(a.File)
+		ah.Arch = machoArch(a.Cpu)
+		h.Arches = append(h.Arches, ah)
+
+		h.PIE = h.PIE && ah.PIE
+		h.NXStack = h.NXStack && ah.NXStack
+		h.Symtab = h.Symtab && ah.Symtab
+		h.DWARF = h.DWARF && ah.DWARF
+		h.Static = h.Static && ah.Static
+		h.Interpreter = cmp.Or(h.Interpreter, ah.Interpreter)
+		for _, lib := range ah.Needed {
+			if !slices.Contains(h.Needed, lib) {
+				h.Needed = append(h.Needed, lib)
+			}
+		}
+	}
+	return h
+}
+
+// machoArch returns the GOARCH of the CPU type, or the CPU type itself if Go does
+// not build for it.
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm:
+		return "arm"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.CpuPpc64:
+		return "ppc64"
+	}
+	return cpu.String()
+}
+
+// hasDWARF reports whether there is a section of the debug info, named like in
+// the ELF and PE files or in the Mach-O ones, possibly compressed.
+func hasDWARF(section func(name string) bool) bool {
+	for _, name := range []string{".debug_info", ".zdebug_info", "__debug_info", "__zdebug_info"} {
+		if section(name) {
+			return true
+		}
+	}
+	return false
+}
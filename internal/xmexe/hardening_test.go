@@ -0,0 +1,93 @@
+package xmexe
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// machoHeader returns a 64-bit Mach-O executable without load commands.
+func machoHeader(cpu macho.Cpu, flags uint32) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, macho.FileHeader{
+		Magic: macho.Magic64,
+		Cpu:   cpu,
+		Type:  macho.TypeExec,
+		Flags: flags,
+	})
+	// The reserved field of the 64-bit header.
+	buf.Write(make([]byte, 4))
+	return buf.Bytes()
+}
+
+// fatFile returns a universal binary of the executables, aligned to 4 KiB.
+func fatFile(files ...[]byte) []byte {
+	const align = 12
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(files))})
+	off := uint32(1 << align)
+	for _, f := range files {
+		cpu := binary.LittleEndian.Uint32(f[4:])
+		binary.Write(&buf, binary.BigEndian, []uint32{cpu, 0, off, uint32(len(f)), align})
+		off += 1 << align
+	}
+	for _, f := range files {
+		buf.Write(make([]byte, 1<<align-buf.Len()%(1<<align)))
+		buf.Write(f)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectFat(t *testing.T) {
+	amd64 := machoHeader(macho.CpuAmd64, macho.FlagPIE)
+	arm64 := machoHeader(macho.CpuArm64, macho.FlagPIE|macho.FlagAllowStackExecution)
+
+	h, err := Inspect(bytes.NewReader(fatFile(amd64, arm64)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Format != FormatMachO {
+		t.Errorf("Format = %q, want %q", h.Format, FormatMachO)
+	}
+	var arches []string
+	for _, a := range h.Arches {
+		arches = append(arches, a.Arch)
+	}
+	if want := []string{"amd64", "arm64"}; !reflect.DeepEqual(arches, want) {
+		t.Fatalf("Arches = %q, want %q", arches, want)
+	}
+	if !h.Arches[0].NXStack || h.Arches[1].NXStack {
+		t.Errorf("NX stack of the arches = %v, %v, want true, false", h.Arches[0].NXStack, h.Arches[1].NXStack)
+	}
+	// The binary has the properties of all the arches only.
+	if !h.PIE || h.NXStack || !h.Static {
+		t.Errorf("PIE, NX stack, static = %v, %v, %v, want true, false, true", h.PIE, h.NXStack, h.Static)
+	}
+
+	h, err = Inspect(bytes.NewReader(amd64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Arches != nil || !h.PIE || !h.NXStack {
+		t.Errorf("thin Mach-O: Arches = %v, PIE = %v, NX stack = %v", h.Arches, h.PIE, h.NXStack)
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"unknown", []byte("#!/bin/sh\n")},
+		// A Java class file shares the magic of the universal binaries.
+		{"java class", []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x41}},
+		{"short", []byte{0x7f}},
+	}
+	for _, tt := range tests {
+		if h, err := Inspect(bytes.NewReader(tt.data)); err == nil {
+			t.Errorf("%s: Inspect = %+v, want error", tt.name, h)
+		}
+	}
+}
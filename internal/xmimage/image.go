@@ -0,0 +1,233 @@
+package xmimage
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/o7q2ab/goxm/internal/xmarchive"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+// Media types of the OCI image indexes and the Docker manifest lists, the others are
+// treated as image manifests.
+var indexMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+var (
+	errUnknownLayout = errors.New("neither manifest.json nor index.json found, expected a docker save tar or an OCI image layout")
+	errNoImages      = errors.New("no image manifests found")
+)
+
+// Scan returns the Go binaries of the images in the docker save tar file or the OCI image
+// layout directory (or tar file), a tar file may be read from the standard input too.
+// The binaries are reported by their paths in the file systems of the images, as they
+// are after all the layers are applied.
+func Scan(name string) ([]*xmreport.Binary, error) {
+	var fsys fs.FS
+	if stat, err := os.Stat(name); err == nil && stat.IsDir() {
+		fsys = os.DirFS(name)
+	} else {
+		src, err := xmsource.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		t, err := openTar(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.Name, err)
+		}
+		fsys = t
+	}
+
+	images, err := readImages(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var bins []*xmreport.Binary
+	for _, img := range images {
+		found, err := img.scan(fsys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", name, img.name, err)
+		}
+		for _, b := range found {
+			b.Archive = name
+		}
+		bins = append(bins, found...)
+	}
+	return bins, nil
+}
+
+// image describes the layers of an image, from the lowest one.
+type image struct {
+	name   string
+	layers []layer
+}
+
+type layer struct {
+	// file is the path of the layer tar in the image archive.
+	file string
+	// id is the digest identifying the layer.
+	id string
+}
+
+// readImages reads the manifest.json file written by docker save, or the index.json
+// file of the OCI image layout if there is none.
+func readImages(fsys fs.FS) ([]*image, error) {
+	images, err := readDockerManifest(fsys)
+	if errors.Is(err, fs.ErrNotExist) {
+		images, err = readOCIIndex(fsys)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errUnknownLayout
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, errNoImages
+	}
+	return images, nil
+}
+
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type dockerConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+func readDockerManifest(fsys fs.FS) ([]*image, error) {
+	var manifests []dockerManifest
+	if err := readJSON(fsys, "manifest.json", &manifests); err != nil {
+		return nil, err
+	}
+	var images []*image
+	for _, m := range manifests {
+		// The layers are identified by the digests of their uncompressed contents,
+		// listed in the image config, falling back to the names of their files.
+		var config dockerConfig
+		if err := readJSON(fsys, xmarchive.CleanPath(m.Config), &config); err != nil {
+			return nil, err
+		}
+		img := &image{name: strings.Join(m.RepoTags, ", ")}
+		if img.name == "" {
+			img.name = path.Base(m.Config)
+		}
+		for i, l := range m.Layers {
+			id := l
+			if len(config.RootFS.DiffIDs) == len(m.Layers) {
+				id = config.RootFS.DiffIDs[i]
+			}
+			img.layers = append(img.layers, layer{file: xmarchive.CleanPath(l), id: id})
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	} `json:"platform"`
+}
+
+type ociIndex struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func readOCIIndex(fsys fs.FS) ([]*image, error) {
+	var index ociIndex
+	if err := readJSON(fsys, "index.json", &index); err != nil {
+		return nil, err
+	}
+	return readOCIManifests(fsys, index.Manifests, "")
+}
+
+// readOCIManifests reads the images of the manifests, descending into the nested indexes
+// of multi-platform images. The name is the one of the nested index.
+func readOCIManifests(fsys fs.FS, descs []ociDescriptor, name string) ([]*image, error) {
+	var images []*image
+	for _, d := range descs {
+		// Attestations and other artifacts are attached as manifests of unknown platforms.
+		if d.Platform != nil && d.Platform.OS == "unknown" {
+			continue
+		}
+		n := cmp.Or(d.Annotations["io.containerd.image.name"], d.Annotations["org.opencontainers.image.ref.name"], name, d.Digest)
+
+		if slices.Contains(indexMediaTypes, d.MediaType) {
+			var index ociIndex
+			if err := readJSON(fsys, blobPath(d.Digest), &index); err != nil {
+				return nil, err
+			}
+			found, err := readOCIManifests(fsys, index.Manifests, n)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, found...)
+			continue
+		}
+
+		var m ociManifest
+		err := readJSON(fsys, blobPath(d.Digest), &m)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Images saved for a single platform miss the manifests of the other ones.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p := d.Platform; p != nil {
+			n += " (" + strings.TrimSuffix(p.OS+"/"+p.Architecture+"/"+p.Variant, "/") + ")"
+		}
+		img := &image{name: n}
+		for _, l := range m.Layers {
+			img.layers = append(img.layers, layer{file: blobPath(l.Digest), id: l.Digest})
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// blobPath returns the path of the blob with the digest, e.g. "blobs/sha256/abc" for "sha256:abc".
+func blobPath(digest string) string {
+	alg, hex, _ := strings.Cut(digest, ":")
+	return xmarchive.CleanPath("blobs/" + alg + "/" + hex)
+}
+
+func readJSON(fsys fs.FS, name string, v any) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(io.LimitReader(f, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
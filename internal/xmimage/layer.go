@@ -0,0 +1,130 @@
+package xmimage
+
+import (
+	"archive/tar"
+	"cmp"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/o7q2ab/goxm/internal/xmarchive"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaque hides all the contents of the directory in the lower layers.
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// scan applies the layers in order and returns the Go binaries of the resulting
+// file system, sorted by their paths.
+func (img *image) scan(fsys fs.FS) ([]*xmreport.Binary, error) {
+	files := map[string]*xmreport.Binary{}
+	for _, l := range img.layers {
+		if err := img.apply(fsys, l, files); err != nil {
+			return nil, err
+		}
+	}
+
+	bins := make([]*xmreport.Binary, 0, len(files))
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		bins = append(bins, files[name])
+	}
+	return bins, nil
+}
+
+// apply updates the Go binaries of the lower layers by their paths with the changes of the layer.
+func (img *image) apply(fsys fs.FS, l layer, files map[string]*xmreport.Binary) error {
+	f, err := fsys.Open(l.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := xmarchive.Decompress(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	added := map[string]*xmreport.Binary{}
+	// removed are the paths replaced or deleted by the layer, opaque are the directories
+	// whose lower contents are hidden. dirs are the directories of the layer, which hide
+	// the lower files of the same paths but are merged with the lower directories.
+	var removed, opaque, dirs []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := xmarchive.CleanPath(hdr.Name)
+		dir, base := path.Split(name)
+		switch {
+		case base == whiteoutOpaque:
+			opaque = append(opaque, strings.TrimSuffix(dir, "/"))
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed = append(removed, dir+strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			dirs = append(dirs, name)
+			delete(added, name)
+			continue
+		}
+		removed = append(removed, name)
+		delete(added, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			b, err := xmsource.ReadStream("/"+name, tr, hdr.ModTime, xmsource.Options{})
+			if err != nil {
+				return err
+			}
+			if b != nil {
+				b.Image, b.Layer = img.name, l.id
+				added[name] = b
+			}
+		case tar.TypeLink:
+			target := xmarchive.CleanPath(hdr.Linkname)
+			if b := cmp.Or(added[target], files[target]); b != nil {
+				link := *b
+				link.File = "/" + name
+				added[name] = &link
+			}
+		}
+	}
+
+	for _, p := range removed {
+		removeTree(files, p)
+	}
+	for _, dir := range opaque {
+		removeTree(files, dir+"/")
+	}
+	for _, dir := range dirs {
+		delete(files, dir)
+	}
+	maps.Copy(files, added)
+	return nil
+}
+
+// removeTree removes the binary at the path and the ones below it if it is a directory.
+// A path ending with a slash removes only the contents of the directory.
+func removeTree(files map[string]*xmreport.Binary, p string) {
+	dir := strings.TrimSuffix(p, "/") + "/"
+	for name := range files {
+		if name == p || strings.HasPrefix(name, dir) {
+			delete(files, name)
+		}
+	}
+}
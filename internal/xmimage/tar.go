@@ -0,0 +1,66 @@
+package xmimage
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/o7q2ab/goxm/internal/xmarchive"
+	"github.com/o7q2ab/goxm/internal/xmsource"
+)
+
+// tarFS is a read-only file system of the regular files of an uncompressed tar file.
+type tarFS struct {
+	src   *xmsource.Source
+	files map[string]*tarEntry
+}
+
+type tarEntry struct {
+	hdr    *tar.Header
+	offset int64
+}
+
+func openTar(src *xmsource.Source) (*tarFS, error) {
+	t := &tarFS{src: src, files: map[string]*tarEntry{}}
+	r := src.Reader()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// The tar reader reads the headers block by block and seeks over the contents,
+		// so the current offset is where the contents of the entry start.
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		t.files[xmarchive.CleanPath(hdr.Name)] = &tarEntry{hdr: hdr, offset: offset}
+	}
+}
+
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e := t.files[name]
+	if e == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &tarFile{SectionReader: io.NewSectionReader(t.src, e.offset, e.hdr.Size), hdr: e.hdr}, nil
+}
+
+type tarFile struct {
+	*io.SectionReader
+	hdr *tar.Header
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.hdr.FileInfo(), nil }
+func (f *tarFile) Close() error               { return nil }
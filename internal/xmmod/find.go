@@ -48,20 +48,26 @@ func Find(start string) (string, error) {
 	return "", errCannotFindGoMod
 }
 
-func FindAll(start string) ([]string, error) {
+// FindAll returns the go.mod files under the start directory. The directories that
+// cannot be read are left out, their errors are returned as skipped.
+func FindAll(start string) (mods []string, skipped []error, err error) {
 	stat, err := os.Stat(start)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if !stat.IsDir() {
-		return nil, fmt.Errorf("%w: %s", errNotDir, start)
+		return nil, nil, fmt.Errorf("%w: %s", errNotDir, start)
 	}
 	curr, err := filepath.Abs(start)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	mods := []string{}
+	mods = []string{}
 	err = filepath.WalkDir(curr, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			skipped = append(skipped, err)
+			return nil
+		}
 		name := d.Name()
 		if slices.Contains(ignoreDirs, name) {
 			return filepath.SkipDir
@@ -72,7 +78,7 @@ func FindAll(start string) ([]string, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return mods, nil
+	return mods, skipped, nil
 }
@@ -0,0 +1,83 @@
+package xmmod
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmproxy"
+)
+
+// LatestMajor looks for major versions of the module above the one of modpath,
+// i.e. for the "/v2", "/v3", ... module paths ("gopkg.in/pkg.v2", ... for gopkg.in),
+// and returns the path and the latest version of the highest one.
+// It returns empty strings if there is no such major version.
+//
+// The probing stops at the first major version which does not exist. Major
+// versions released before the module was converted to Go modules show up as
+// "+incompatible" versions and are skipped over.
+func (r *Resolver) LatestMajor(modpath string) (path, version string, err error) {
+	prefix, pathMajor, ok := module.SplitPathVersion(modpath)
+	if !ok {
+		return "", "", fmt.Errorf("malformed module path %q", modpath)
+	}
+	sep := "/"
+	if strings.HasPrefix(modpath, "gopkg.in/") {
+		sep = "."
+	}
+
+	major := 1
+	if pathMajor != "" {
+		major, err = strconv.Atoi(strings.TrimLeft(pathMajor, "/.v"))
+		if err != nil {
+			return "", "", fmt.Errorf("malformed module path %q: %w", modpath, err)
+		}
+	} else if versions, err := r.Versions(modpath); err == nil {
+		for _, v := range versions {
+			if isIncompatible(v) {
+				major = max(major, majorNumber(v))
+			}
+		}
+	}
+
+	for n := major + 1; ; n++ {
+		p := prefix + sep + "v" + strconv.Itoa(n)
+		versions, err := r.versions(r.probe, p)
+		if err != nil || len(versions) == 0 {
+			break
+		}
+		path, version = p, latestFor(versions, "")
+	}
+	return path, version, nil
+}
+
+// latestFor returns the latest version of the list, ignoring the "+incompatible"
+// versions of modules which also have compatible ones, the same way the go command does
+// for modules with a go.mod file, unless the current version is "+incompatible".
+func latestFor(versions []string, current string) string {
+	if isIncompatible(current) {
+		return xmproxy.Max(versions)
+	}
+	compatible := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if !isIncompatible(v) {
+			compatible = append(compatible, v)
+		}
+	}
+	if len(compatible) != 0 {
+		return xmproxy.Max(compatible)
+	}
+	return xmproxy.Max(versions)
+}
+
+func isIncompatible(v string) bool {
+	return semver.Build(v) == "+incompatible"
+}
+
+func majorNumber(v string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(semver.Major(v), "v"))
+	return n
+}
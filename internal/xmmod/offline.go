@@ -0,0 +1,126 @@
+package xmmod
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmenv"
+	"github.com/o7q2ab/goxm/internal/xmproxy"
+)
+
+// modCache answers lookups from the download cache of the go command
+// ($GOMODCACHE/cache/download), without any network access.
+type modCache struct {
+	dir string
+}
+
+func newModCache() *modCache {
+	return &modCache{dir: filepath.Join(xmenv.Get("GOMODCACHE"), "cache", "download")}
+}
+
+// Versions returns the tagged versions found in the cache sorted in semver order.
+func (c *modCache) Versions(modpath string) ([]string, error) {
+	all, err := c.all(modpath)
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for _, v := range all {
+		if !module.IsPseudoVersion(v) {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// Info reads the metadata of the given module version from the cache.
+// The "latest" version is the highest cached pseudo-version, since
+// modules with tagged versions are expected to be resolved by Versions.
+func (c *modCache) Info(modpath, version string) (*xmproxy.Info, error) {
+	if version == "latest" {
+		all, err := c.all(modpath)
+		if err != nil {
+			return nil, err
+		}
+		if len(all) == 0 {
+			return nil, c.notFound(modpath)
+		}
+		version = all[len(all)-1]
+	}
+
+	dir, err := c.moduleDir(modpath)
+	if err != nil {
+		return nil, err
+	}
+	v, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, v+".info"))
+	if err != nil {
+		return nil, err
+	}
+	info := &xmproxy.Info{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// all returns the versions listed in the "list" file of the module
+// together with the ones which only have an .info file.
+func (c *modCache) all(modpath string) ([]string, error) {
+	dir, err := c.moduleDir(modpath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, c.notFound(modpath)
+	}
+
+	seen := map[string]bool{}
+	if data, err := os.ReadFile(filepath.Join(dir, "list")); err == nil {
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for s.Scan() {
+			seen[strings.TrimSpace(s.Text())] = true
+		}
+	}
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".info"); ok {
+			if v, err := module.UnescapeVersion(name); err == nil {
+				seen[v] = true
+			}
+		}
+	}
+
+	versions := []string{}
+	for v := range seen {
+		if semver.IsValid(v) {
+			versions = append(versions, v)
+		}
+	}
+	semver.Sort(versions)
+	return versions, nil
+}
+
+func (c *modCache) moduleDir(modpath string) (string, error) {
+	escaped, err := module.EscapePath(modpath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, filepath.FromSlash(escaped), "@v"), nil
+}
+
+func (c *modCache) notFound(modpath string) error {
+	return fmt.Errorf("%s: not found in the module cache %s: %w", modpath, c.dir, fs.ErrNotExist)
+}
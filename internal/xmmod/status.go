@@ -0,0 +1,132 @@
+package xmmod
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Status is what the go.mod file of the latest version of a module says about the module:
+// whether it is deprecated and which of its versions are retracted.
+type Status struct {
+	// Version is the version whose go.mod file was read.
+	Version    string
+	Deprecated string
+	Retract    []*modfile.Retract
+}
+
+// Retracted returns the retract directive covering the version, or nil if it is not retracted.
+func (s *Status) Retracted(version string) *modfile.Retract {
+	for _, r := range s.Retract {
+		if semver.Compare(r.Low, version) <= 0 && semver.Compare(version, r.High) <= 0 {
+			return r
+		}
+	}
+	return nil
+}
+
+// Status reads the go.mod file of the latest version of the module, ignoring retractions,
+// the same way the go command does to find out the deprecations and the retractions.
+func (r *Resolver) Status(modpath, current string) (*Status, error) {
+	versions, err := r.Versions(modpath)
+	if err != nil {
+		return nil, err
+	}
+	v := latestFor(versions, current)
+	if v == "" {
+		info, err := r.Info(modpath, "latest")
+		if err != nil {
+			return nil, err
+		}
+		v = info.Version
+	}
+	return r.status(modpath, v)
+}
+
+type statusResult struct {
+	status *Status
+	err    error
+}
+
+// status returns the status from the go.mod file of the module version, which is
+// only read once.
+func (r *Resolver) status(modpath, version string) (*Status, error) {
+	key := modpath + "@" + version
+	r.mu.Lock()
+	res, ok := r.statuses[key]
+	r.mu.Unlock()
+	if !ok {
+		res = &statusResult{}
+		res.status, res.err = r.readStatus(modpath, version)
+		r.mu.Lock()
+		if r.statuses == nil {
+			r.statuses = map[string]*statusResult{}
+		}
+		r.statuses[key] = res
+		r.mu.Unlock()
+	}
+	return res.status, res.err
+}
+
+func (r *Resolver) readStatus(modpath, version string) (*Status, error) {
+	data, err := r.GoMod(modpath, version)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax(modpath+"@"+version+"/go.mod", data, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{Version: version, Retract: f.Retract}
+	if f.Module != nil {
+		s.Deprecated = f.Module.Deprecated
+	}
+	return s, nil
+}
+
+// withoutRetracted returns the versions which are not retracted by the go.mod
+// file of the latest one, if it can be read.
+func (r *Resolver) withoutRetracted(modpath, latest string, versions []string) []string {
+	s, err := r.status(modpath, latest)
+	if err != nil || s.Retracted(latest) == nil {
+		return versions
+	}
+	return slices.DeleteFunc(slices.Clone(versions), func(v string) bool {
+		return s.Retracted(v) != nil
+	})
+}
+
+// GoMod returns the content of the go.mod file of the given module version.
+func (r *Resolver) GoMod(modpath, version string) ([]byte, error) {
+	v, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if r.modcache != nil {
+		dir, err := r.modcache.moduleDir(modpath)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Join(dir, v+".mod"))
+	}
+
+	key, err := cacheKey(modpath, "@v/"+v+".mod")
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if r.cache.Get(key, &data) {
+		return data, nil
+	}
+	data, err = r.client.GoMod(context.Background(), modpath, version)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Put(key, data)
+	return data, nil
+}
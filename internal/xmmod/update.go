@@ -0,0 +1,34 @@
+package xmmod
+
+import (
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Kinds of updates, from the least to the most disruptive one.
+const (
+	UpdatePatch  = "patch"
+	UpdateMinor  = "minor"
+	UpdateMajor  = "major"
+	UpdatePseudo = "pseudo"
+)
+
+var UpdateKinds = []string{UpdatePatch, UpdateMinor, UpdateMajor, UpdatePseudo}
+
+// ClassifyUpdate returns the kind of the update from the current version to the latest one,
+// or an empty string if the latest version is not newer. Updates from or to pseudo-versions
+// are not comparable by their numbers and are classified as "pseudo".
+func ClassifyUpdate(current, latest string) string {
+	if !semver.IsValid(current) || !semver.IsValid(latest) || semver.Compare(latest, current) <= 0 {
+		return ""
+	}
+	switch {
+	case module.IsPseudoVersion(current) || module.IsPseudoVersion(latest):
+		return UpdatePseudo
+	case semver.Major(current) != semver.Major(latest):
+		return UpdateMajor
+	case semver.MajorMinor(current) != semver.MajorMinor(latest):
+		return UpdateMinor
+	}
+	return UpdatePatch
+}
@@ -1,31 +1,194 @@
 package xmmod
 
 import (
-	"encoding/json"
-	"os/exec"
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"github.com/o7q2ab/goxm/internal/xmcache"
+	"github.com/o7q2ab/goxm/internal/xmenv"
+	"github.com/o7q2ab/goxm/internal/xmproxy"
+)
+
+const (
+	DefaultCacheTTL = 24 * time.Hour
+	DefaultRate     = 50
 )
 
-type goListOutput struct {
-	Versions []string `json:"Versions"`
+// Config controls how module versions are looked up.
+type Config struct {
+	// CacheTTL is how long looked up versions are reused, zero disables the cache.
+	CacheTTL time.Duration
+	// Refresh bypasses the cached versions and stores fresh ones instead.
+	Refresh bool
+	// Rate is the maximum number of network requests per second, zero means no limit.
+	Rate int
+	// Offline answers lookups from the local module cache only.
+	// It is implied by GOPROXY=off and GOFLAGS=-mod=mod.
+	Offline bool
+}
+
+var (
+	mu     sync.Mutex
+	config = Config{CacheTTL: DefaultCacheTTL, Rate: DefaultRate}
+	def    *Resolver
+)
+
+// Configure sets the configuration used by GetLatest and DefaultResolver.
+func Configure(c Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	config = c
+	def = nil
+}
+
+// DefaultResolver returns the resolver configured by the environment and Configure.
+func DefaultResolver() (*Resolver, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if def != nil {
+		return def, nil
+	}
+
+	if mod, _ := xmenv.Flag("mod"); config.Offline || mod == "mod" || xmenv.Get("GOPROXY") == "off" {
+		def = &Resolver{modcache: newModCache()}
+		return def, nil
+	}
+
+	client, err := xmproxy.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client.Limiter = xmproxy.NewLimiter(config.Rate)
+	cache := &xmcache.Cache{TTL: config.CacheTTL, Refresh: config.Refresh}
+	if cache.Dir, err = xmcache.DefaultDir(); err != nil {
+		cache = nil
+	}
+	def = NewResolver(client, cache)
+	return def, nil
 }
 
 // GetLatest returns the latest available version for the given module.
-func GetLatest(modpath string) string {
-	cmd := exec.Command(
-		// More about `go list`: https://pkg.go.dev/cmd/go#hdr-List_packages_or_modules
-		"go", "list", "-m", "-e", "-json=Versions", "-mod=mod", "-versions", modpath,
-	)
+func GetLatest(modpath string) (string, error) {
+	r, err := DefaultResolver()
+	if err != nil {
+		return "", err
+	}
+	return r.Latest(modpath, "")
+}
+
+// Resolver looks up module versions with the proxy client and remembers them in the cache.
+// In offline mode it looks them up in the local module cache instead.
+// It is safe for concurrent use.
+type Resolver struct {
+	client *xmproxy.Client
+	// probe is used for the module paths which may not exist at all.
+	probe    *xmproxy.Client
+	cache    *xmcache.Cache
+	modcache *modCache
+
+	// statuses are the go.mod files read in this run by "path@version", shared by
+	// the lookups of the latest versions and of the retractions and deprecations.
+	mu       sync.Mutex
+	statuses map[string]*statusResult
+}
+
+func NewResolver(client *xmproxy.Client, cache *xmcache.Cache) *Resolver {
+	return &Resolver{client: client, probe: client.WithoutDirect(), cache: cache}
+}
+
+// Offline reports whether the resolver only knows the versions found in the local module cache.
+func (r *Resolver) Offline() bool {
+	return r.modcache != nil
+}
+
+// Versions returns the known tagged versions of the module sorted in semver order.
+// A module unknown to the proxies has no versions.
+func (r *Resolver) Versions(modpath string) ([]string, error) {
+	return r.versions(r.client, modpath)
+}
+
+func (r *Resolver) versions(client *xmproxy.Client, modpath string) ([]string, error) {
+	if r.modcache != nil {
+		return r.modcache.Versions(modpath)
+	}
+	key, err := cacheKey(modpath, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	if r.cache.Get(key, &versions) {
+		return versions, nil
+	}
+	versions, err = client.Versions(context.Background(), modpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		versions, err = []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Put(key, versions)
+	return versions, nil
+}
+
+// Info returns the metadata of the given module version, or of the "latest" one.
+func (r *Resolver) Info(modpath, version string) (*xmproxy.Info, error) {
+	if r.modcache != nil {
+		return r.modcache.Info(modpath, version)
+	}
+	file := "@latest"
+	if version != "latest" {
+		v, err := module.EscapeVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		file = "@v/" + v + ".info"
+	}
+	key, err := cacheKey(modpath, file)
+	if err != nil {
+		return nil, err
+	}
+	info := &xmproxy.Info{}
+	if r.cache.Get(key, info) {
+		return info, nil
+	}
+	info, err = r.client.Info(context.Background(), modpath, version)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Put(key, info)
+	return info, nil
+}
 
-	out, err := cmd.CombinedOutput()
+// Latest returns the version the "latest" query resolves to, skipping the versions
+// retracted by the latest go.mod file. The "+incompatible" versions are considered only
+// for modules without compatible versions, or if the current version is "+incompatible" itself.
+func (r *Resolver) Latest(modpath, current string) (string, error) {
+	versions, err := r.Versions(modpath)
 	if err != nil {
-		return ""
+		return "", err
+	}
+	if v := latestFor(versions, current); v != "" {
+		if allowed := r.withoutRetracted(modpath, v, versions); len(allowed) != 0 {
+			v = latestFor(allowed, current)
+		}
+		return v, nil
 	}
-	result := goListOutput{}
-	if err = json.Unmarshal(out, &result); err != nil {
-		return ""
+	info, err := r.Info(modpath, "latest")
+	if err != nil {
+		return "", err
 	}
-	if len(result.Versions) == 0 {
-		return ""
+	return info.Version, nil
+}
+
+func cacheKey(modpath, file string) (string, error) {
+	escaped, err := module.EscapePath(modpath)
+	if err != nil {
+		return "", err
 	}
-	return result.Versions[len(result.Versions)-1]
+	return "download/" + escaped + "/" + file, nil
 }
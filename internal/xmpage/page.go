@@ -0,0 +1,148 @@
+package xmpage
+
+import (
+	"cmp"
+	_ "embed"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmmod"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+// topDeps is the number of the most common dependency modules shown.
+const topDeps = 15
+
+var (
+	//go:embed report.html.tmpl
+	htmlText string
+	//go:embed report.md.tmpl
+	markdownText string
+
+	funcs = map[string]any{
+		"pkgURL": pkgURL,
+		"md":     markdownEscape,
+	}
+
+	htmlTemplate     = htmltemplate.Must(htmltemplate.New("report").Funcs(funcs).Parse(htmlText))
+	markdownTemplate = template.Must(template.New("report").Funcs(funcs).Parse(markdownText))
+)
+
+// page is the data of the report templates.
+type page struct {
+	*xmreport.Report
+
+	GoVersions []*bar
+	TopDeps    []*bar
+	// Summary describes the outdated modules, it is nil unless their latest versions were looked up.
+	Summary *xmreport.Summary
+	Updates []*bar
+}
+
+// bar is an entry of a chart, Percent is relative to the largest entry.
+type bar struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// HTML writes the inventory as a self-contained HTML page.
+func HTML(w io.Writer, r *xmreport.Report) error {
+	return htmlTemplate.Execute(w, newPage(r))
+}
+
+// Markdown writes the inventory as a Markdown document.
+func Markdown(w io.Writer, r *xmreport.Report) error {
+	return markdownTemplate.Execute(w, newPage(r))
+}
+
+func newPage(r *xmreport.Report) *page {
+	p := &page{Report: r}
+
+	bins := slices.Clone(r.Binaries)
+	for _, pr := range r.Processes {
+		bins = append(bins, pr.Binary)
+	}
+
+	goVersions := map[string]int{}
+	deps := map[string]int{}
+	var mods []*xmreport.Module
+	for _, b := range bins {
+		goVersions[b.GoVersion]++
+		for _, d := range b.Deps {
+			deps[d.Path]++
+		}
+		mods = append(mods, b.Deps...)
+	}
+	for _, m := range r.Modules {
+		for _, req := range m.Requires {
+			deps[req.Path]++
+			mods = append(mods, req.Module)
+		}
+	}
+
+	versions := slices.SortedFunc(maps.Keys(goVersions), func(a, b string) int {
+		return semver.Compare(xmvuln.GoVersion(b), xmvuln.GoVersion(a))
+	})
+	for _, v := range versions {
+		p.GoVersions = append(p.GoVersions, &bar{Label: v, Count: goVersions[v]})
+	}
+	scale(p.GoVersions)
+
+	paths := slices.SortedFunc(maps.Keys(deps), func(a, b string) int {
+		return cmp.Or(cmp.Compare(deps[b], deps[a]), strings.Compare(a, b))
+	})
+	for _, path := range paths[:min(len(paths), topDeps)] {
+		p.TopDeps = append(p.TopDeps, &bar{Label: path, Count: deps[path]})
+	}
+	scale(p.TopDeps)
+
+	if slices.ContainsFunc(mods, func(m *xmreport.Module) bool { return m.Latest != nil }) {
+		p.Summary = xmreport.Summarize(mods)
+		for _, kind := range xmmod.UpdateKinds {
+			if n := p.Summary.Updates[kind]; n != 0 {
+				p.Updates = append(p.Updates, &bar{Label: kind, Count: n})
+			}
+		}
+		scale(p.Updates)
+	}
+	return p
+}
+
+// Blocks draws the bar for the text formats.
+func (b *bar) Blocks() string {
+	return strings.Repeat("█", max(b.Percent/5, 1))
+}
+
+func scale(bars []*bar) {
+	top := 0
+	for _, b := range bars {
+		top = max(top, b.Count)
+	}
+	for _, b := range bars {
+		b.Percent = b.Count * 100 / max(top, 1)
+	}
+}
+
+// pkgURL returns the pkg.go.dev page of the module version.
+func pkgURL(path, version string) string {
+	u := "https://pkg.go.dev/" + path
+	// Versions of local builds, e.g. "+dirty" ones, are not published.
+	if semver.IsValid(version) && (semver.Build(version) == "" || semver.Build(version) == "+incompatible") {
+		u += "@" + version
+	}
+	return u
+}
+
+var markdownReplacer = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;", "*", `\*`, "_", `\_`, "`", "\\`")
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
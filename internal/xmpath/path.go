@@ -1,11 +1,38 @@
 package xmpath
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 )
 
+// Options control how the directories are listed.
+type Options struct {
+	// Recursive lists the files of the subdirectories too.
+	Recursive bool
+	// MaxDepth limits the recursion, the files of the listed directory are at depth 1.
+	// Zero means no limit.
+	MaxDepth int
+	// Include and Exclude are glob patterns matched against the file names and their
+	// paths relative to the listed directory. If Include is set, only the matching files
+	// are listed. Excluded directories are not descended into.
+	Include, Exclude []string
+	// FollowSymlinks descends into symbolic links to directories.
+	FollowSymlinks bool
+}
+
+// Validate checks the syntax of the patterns.
+func (o *Options) Validate() error {
+	for _, p := range slices.Concat(o.Include, o.Exclude) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func ListPathEnv() []string {
 	var raw string
 	if runtime.GOOS == "windows" {
@@ -17,34 +44,96 @@ func ListPathEnv() []string {
 	names := []string{}
 	all := filepath.SplitList(raw)
 	for _, one := range all {
-		names = append(names, listdir(one)...)
+		names = append(names, listdir(one, &Options{})...)
 	}
 
 	return names
 }
 
+// List returns the file itself, or the regular files in the directory.
 func List(p string) []string {
+	return Walk(p, &Options{})
+}
+
+// Walk is like List, listing the directory as configured by the options.
+func Walk(p string, opts *Options) []string {
 	stat, err := os.Stat(p)
 	if err != nil {
 		return []string{}
 	}
 	if stat.IsDir() {
-		return listdir(p)
+		return listdir(p, opts)
 	}
 	return []string{p}
 }
 
-func listdir(p string) []string {
+func listdir(p string, opts *Options) []string {
+	w := &walker{opts: opts, root: p, names: []string{}, visited: map[string]bool{}}
+	w.dir(p, 1)
+	return w.names
+}
+
+type walker struct {
+	opts  *Options
+	root  string
+	names []string
+	// visited are the real paths of the listed directories, to break symbolic link cycles.
+	visited map[string]bool
+}
+
+func (w *walker) dir(p string, depth int) {
+	if w.opts.FollowSymlinks {
+		real, err := filepath.EvalSymlinks(p)
+		if err != nil || w.visited[real] {
+			return
+		}
+		w.visited[real] = true
+	}
+
 	files, err := os.ReadDir(p)
 	if err != nil {
-		return nil
+		return
 	}
-	names := []string{}
 	for _, file := range files {
-		if file.IsDir() {
+		name := filepath.Join(p, file.Name())
+		rel, _ := filepath.Rel(w.root, name)
+		if w.match(w.opts.Exclude, file.Name(), rel) {
 			continue
 		}
-		names = append(names, filepath.Join(p, file.Name()))
+
+		typ := file.Type()
+		if typ&fs.ModeSymlink != 0 {
+			stat, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			if stat.IsDir() && !w.opts.FollowSymlinks {
+				continue
+			}
+			typ = stat.Mode().Type()
+		}
+
+		switch {
+		case typ.IsDir():
+			if w.opts.Recursive && (w.opts.MaxDepth == 0 || depth < w.opts.MaxDepth) {
+				w.dir(name, depth+1)
+			}
+		case typ.IsRegular():
+			if len(w.opts.Include) == 0 || w.match(w.opts.Include, file.Name(), rel) {
+				w.names = append(w.names, name)
+			}
+		}
 	}
-	return names
+}
+
+func (w *walker) match(patterns []string, name, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,194 @@
+package xmpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+// Rules reported in the violations.
+const (
+	RuleDeny         = "deny"
+	RuleAllow        = "allow"
+	RuleMinVersion   = "min_version"
+	RuleMinGoVersion = "min_go_version"
+	RuleBuildSetting = "build_setting"
+	RuleLocalReplace = "local_replace"
+)
+
+// Policy is the set of rules the targets must follow. The module path patterns
+// are globs matching path prefixes, like the ones in GOPRIVATE.
+type Policy struct {
+	// Allow, if not empty, lists the only dependency modules allowed.
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists the dependency modules not allowed.
+	Deny []string `json:"deny,omitempty"`
+	// MinVersions are the lowest allowed versions of the dependency modules, checked
+	// against the replacements of the replaced ones. A path without the major version
+	// suffix applies to all the major versions of the module.
+	MinVersions map[string]string `json:"min_versions,omitempty"`
+	// MinGoVersion is the lowest allowed Go version, e.g. "go1.22.2". The development
+	// versions of Go are taken as the newest ones.
+	MinGoVersion string `json:"min_go_version,omitempty"`
+	// BuildSettings are the required values of the build settings of binaries,
+	// e.g. "-trimpath": "true" or "CGO_ENABLED": "0".
+	BuildSettings map[string]string `json:"build_settings,omitempty"`
+	// DenyLocalReplace forbids replacing modules with local directories.
+	DenyLocalReplace bool `json:"deny_local_replace,omitempty"`
+}
+
+// Load reads the policy from the JSON file.
+func Load(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if p.MinGoVersion != "" && goVersion(p.MinGoVersion) == "" {
+		return nil, fmt.Errorf("%s: invalid min_go_version %q", name, p.MinGoVersion)
+	}
+	for path, v := range p.MinVersions {
+		if !semver.IsValid(v) {
+			return nil, fmt.Errorf("%s: invalid min_versions version %q of %s", name, v, path)
+		}
+	}
+	return p, nil
+}
+
+// Check returns the violations of the policy by the target.
+func (p *Policy) Check(t xmreport.Target) []*xmreport.Violation {
+	switch t := t.(type) {
+	case *xmreport.Binary:
+		return p.checkBinary(t)
+	case *xmreport.Process:
+		return p.checkBinary(t.Binary)
+	case *xmreport.GoMod:
+		var vs []*xmreport.Violation
+		if t.GoVersion != "" {
+			vs = p.checkGoVersion("go" + t.GoVersion)
+		}
+		for _, r := range t.Requires {
+			vs = append(vs, p.checkModule(r.Module)...)
+		}
+		return vs
+	}
+	return nil
+}
+
+func (p *Policy) checkBinary(b *xmreport.Binary) []*xmreport.Violation {
+	vs := p.checkGoVersion(b.GoVersion)
+	for _, d := range b.Deps {
+		vs = append(vs, p.checkModule(d)...)
+	}
+	for _, key := range slices.Sorted(maps.Keys(p.BuildSettings)) {
+		want := p.BuildSettings[key]
+		got, ok := b.Setting(key)
+		if !ok {
+			vs = append(vs, &xmreport.Violation{
+				Rule:    RuleBuildSetting,
+				Message: fmt.Sprintf("build setting %s is not set, want %q", key, want),
+			})
+		} else if got != want {
+			vs = append(vs, &xmreport.Violation{
+				Rule:    RuleBuildSetting,
+				Message: fmt.Sprintf("build setting %s is %q, want %q", key, got, want),
+			})
+		}
+	}
+	return vs
+}
+
+func (p *Policy) checkGoVersion(v string) []*xmreport.Violation {
+	if p.MinGoVersion == "" {
+		return nil
+	}
+	if strings.HasPrefix(v, "devel") {
+		return nil
+	}
+	got, min := goVersion(v), goVersion(p.MinGoVersion)
+	if got != "" && semver.Compare(got, min) >= 0 {
+		return nil
+	}
+	return []*xmreport.Violation{{
+		Rule:    RuleMinGoVersion,
+		Message: fmt.Sprintf("Go version %s is lower than %s", v, p.MinGoVersion),
+	}}
+}
+
+func (p *Policy) checkModule(m *xmreport.Module) []*xmreport.Violation {
+	var vs []*xmreport.Violation
+	add := func(rule, format string, args ...any) {
+		vs = append(vs, &xmreport.Violation{
+			Rule:    rule,
+			Module:  m.Path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	path, version := m.Path, m.Version
+	if r := m.Replace; r != nil {
+		if r.Version == "" && modfile.IsDirectoryPath(r.Path) {
+			if p.DenyLocalReplace {
+				add(RuleLocalReplace, "module %s is replaced with the local directory %s", m.Path, r.Path)
+			}
+		} else {
+			path, version = r.Path, r.Version
+		}
+	}
+
+	for _, mp := range slices.Compact([]string{m.Path, path}) {
+		if matches(p.Deny, mp) {
+			add(RuleDeny, "module %s is denied", mp)
+		}
+		if len(p.Allow) != 0 && !matches(p.Allow, mp) {
+			add(RuleAllow, "module %s is not allowed", mp)
+		}
+	}
+	// The lowest version applies to the module in use, the replacement if there is one.
+	if min, ok := p.minVersion(path); ok && version != "" {
+		if !semver.IsValid(version) || semver.Compare(version, min) < 0 {
+			add(RuleMinVersion, "module %s %s is lower than %s", path, version, min)
+		}
+	}
+	return vs
+}
+
+// minVersion returns the lowest allowed version of the module, set for its path or
+// for the path without the major version suffix, e.g. "example.com/mod" for
+// "example.com/mod/v2".
+func (p *Policy) minVersion(modpath string) (string, bool) {
+	if min, ok := p.MinVersions[modpath]; ok {
+		return min, true
+	}
+	prefix, pathMajor, ok := module.SplitPathVersion(modpath)
+	if !ok || pathMajor == "" {
+		return "", false
+	}
+	min, ok := p.MinVersions[prefix]
+	return min, ok
+}
+
+func matches(patterns []string, modpath string) bool {
+	return len(patterns) != 0 && module.MatchPrefixPatterns(strings.Join(patterns, ","), modpath)
+}
+
+// goVersion converts a Go version like "go1.22.2" or "1.22" to a semantic version.
+func goVersion(v string) string {
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	return xmvuln.GoVersion(v)
+}
@@ -0,0 +1,77 @@
+package xmproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The "direct" GOPROXY entry means fetching from version control systems,
+// which is delegated to the go command since it already knows how to do it.
+
+type goListOutput struct {
+	Versions []string `json:"Versions"`
+	Info
+	GoMod string `json:"GoMod"`
+	Error *struct {
+		Err string `json:"Err"`
+	} `json:"Error"`
+}
+
+func directVersions(ctx context.Context, path string) ([]string, error) {
+	// More about `go list`: https://pkg.go.dev/cmd/go#hdr-List_packages_or_modules
+	out, err := runGo(ctx, "list", "-m", "-json", "-versions", path)
+	if err != nil {
+		return nil, err
+	}
+	return out.Versions, nil
+}
+
+func directInfo(ctx context.Context, path, version string) (*Info, error) {
+	out, err := runGo(ctx, "list", "-m", "-json", path+"@"+version)
+	if err != nil {
+		return nil, err
+	}
+	return &out.Info, nil
+}
+
+func directGoMod(ctx context.Context, path, version string) ([]byte, error) {
+	out, err := runGo(ctx, "mod", "download", "-json", path+"@"+version)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(out.GoMod)
+}
+
+func runGo(ctx context.Context, args ...string) (*goListOutput, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	// Run outside of any module so that the current go.mod does not affect the queries.
+	cmd.Dir = os.TempDir()
+	cmd.Env = append(os.Environ(), "GOPROXY=direct", "GOFLAGS=")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	out := &goListOutput{}
+	if stdout.Len() != 0 {
+		if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+			return nil, err
+		}
+	}
+	if out.Error != nil {
+		return nil, errors.New(out.Error.Err)
+	}
+	if runErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, runErr
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package xmproxy
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter spaces out requests so that no more than the given number are sent per second.
+// A nil Limiter does not limit anything.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewLimiter returns a limiter allowing perSecond requests per second,
+// or nil if perSecond is not positive.
+func NewLimiter(perSecond int) *Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &Limiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait blocks until the next request is allowed to be sent.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,340 @@
+package xmproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmenv"
+)
+
+const (
+	direct = "direct"
+	off    = "off"
+)
+
+var (
+	errOff       = errors.New("module lookup disabled by GOPROXY=off")
+	errEmptyList = errors.New("GOPROXY list is empty")
+)
+
+// Info is the metadata served by the proxy for a single module version.
+type Info struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+// Proxy is a single entry of the GOPROXY list.
+type Proxy struct {
+	// URL is the base URL of the proxy, or one of the "direct" and "off" keywords.
+	URL string
+	// FallbackOnError reports whether the next entry is tried after any error
+	// ("|" separator), rather than only after a "not found" one ("," separator).
+	FallbackOnError bool
+}
+
+// Client is a client for the module proxy protocol described in
+// https://go.dev/ref/mod#goproxy-protocol.
+type Client struct {
+	Proxies []Proxy
+	// NoProxy is a comma-separated list of module path glob patterns
+	// which are always fetched directly (GONOPROXY).
+	NoProxy string
+	HTTP    *http.Client
+	// Limiter spaces out the requests to the network, nil means no limit.
+	Limiter *Limiter
+}
+
+// FromEnv returns a client configured by GOPROXY, GONOPROXY and GOPRIVATE.
+func FromEnv() (*Client, error) {
+	return New(xmenv.Get("GOPROXY"), xmenv.Get("GONOPROXY"))
+}
+
+func New(goproxy, noproxy string) (*Client, error) {
+	proxies, err := ParseList(goproxy)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Proxies: proxies,
+		NoProxy: noproxy,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// WithoutDirect returns a copy of the client which does not fall back to fetching
+// from version control systems, unless it is the only way the client can fetch modules.
+// It is meant for speculative queries which are expected to fail.
+func (c *Client) WithoutDirect() *Client {
+	proxies := make([]Proxy, 0, len(c.Proxies))
+	for _, p := range c.Proxies {
+		if p.URL != direct {
+			proxies = append(proxies, p)
+		}
+	}
+	if len(proxies) == 0 {
+		return c
+	}
+	cc := *c
+	cc.Proxies = proxies
+	return &cc
+}
+
+// ParseList parses the value of GOPROXY.
+func ParseList(goproxy string) ([]Proxy, error) {
+	var proxies []Proxy
+	for goproxy != "" {
+		var entry string
+		fallbackOnError := false
+		if i := strings.IndexAny(goproxy, ",|"); i >= 0 {
+			entry = goproxy[:i]
+			fallbackOnError = goproxy[i] == '|'
+			goproxy = goproxy[i+1:]
+		} else {
+			entry = goproxy
+			goproxy = ""
+		}
+
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case direct, off:
+		default:
+			if !strings.Contains(entry, "://") {
+				entry = "https://" + entry
+			}
+			u, err := url.Parse(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid GOPROXY entry %q: %w", entry, err)
+			}
+			switch u.Scheme {
+			case "http", "https", "file":
+			default:
+				return nil, fmt.Errorf("invalid GOPROXY entry %q: unsupported scheme %q", entry, u.Scheme)
+			}
+			entry = strings.TrimSuffix(entry, "/")
+		}
+		proxies = append(proxies, Proxy{URL: entry, FallbackOnError: fallbackOnError})
+	}
+	if len(proxies) == 0 {
+		return nil, errEmptyList
+	}
+	return proxies, nil
+}
+
+// Versions returns the known tagged versions of the module sorted in semver order.
+func (c *Client) Versions(ctx context.Context, path string) ([]string, error) {
+	var versions []string
+	err := c.each(ctx, path, func(p Proxy) error {
+		if p.URL == direct {
+			var err error
+			versions, err = directVersions(ctx, path)
+			return err
+		}
+		data, err := c.fetch(ctx, p, path, "@v/list")
+		if err != nil {
+			return err
+		}
+		versions = versions[:0]
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for s.Scan() {
+			// Lines may carry extra fields after the version.
+			f := strings.Fields(s.Text())
+			if len(f) != 0 && semver.IsValid(f[0]) {
+				versions = append(versions, f[0])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	semver.Sort(versions)
+	return versions, nil
+}
+
+// Max returns the highest release version, falling back to the highest
+// pre-release version if the list does not contain any release.
+func Max(versions []string) string {
+	release, prerelease := "", ""
+	for _, v := range versions {
+		if semver.Prerelease(v) != "" {
+			if prerelease == "" || semver.Compare(v, prerelease) > 0 {
+				prerelease = v
+			}
+		} else if release == "" || semver.Compare(v, release) > 0 {
+			release = v
+		}
+	}
+	if release != "" {
+		return release
+	}
+	return prerelease
+}
+
+// Info returns the metadata of the given module version.
+// The version "latest" queries the @latest endpoint.
+func (c *Client) Info(ctx context.Context, path, version string) (*Info, error) {
+	var info *Info
+	err := c.each(ctx, path, func(p Proxy) error {
+		if p.URL == direct {
+			var err error
+			info, err = directInfo(ctx, path, version)
+			return err
+		}
+		file := "@latest"
+		if version != "latest" {
+			v, err := module.EscapeVersion(version)
+			if err != nil {
+				return err
+			}
+			file = "@v/" + v + ".info"
+		}
+		data, err := c.fetch(ctx, p, path, file)
+		if err != nil {
+			return err
+		}
+		info = &Info{}
+		return json.Unmarshal(data, info)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GoMod returns the content of the go.mod file of the given module version.
+func (c *Client) GoMod(ctx context.Context, path, version string) ([]byte, error) {
+	var data []byte
+	err := c.each(ctx, path, func(p Proxy) error {
+		var err error
+		if p.URL == direct {
+			data, err = directGoMod(ctx, path, version)
+			return err
+		}
+		v, err := module.EscapeVersion(version)
+		if err != nil {
+			return err
+		}
+		data, err = c.fetch(ctx, p, path, "@v/"+v+".mod")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// each calls fn for the proxies in order until one of them succeeds,
+// following the fallback rules of the GOPROXY list.
+func (c *Client) each(ctx context.Context, path string, fn func(Proxy) error) error {
+	proxies := c.Proxies
+	if c.NoProxy != "" && module.MatchPrefixPatterns(c.NoProxy, path) {
+		proxies = []Proxy{{URL: direct}}
+	}
+
+	err := errEmptyList
+	for _, p := range proxies {
+		if p.URL == off {
+			return errOff
+		}
+		if !strings.HasPrefix(p.URL, "file://") {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		err = fn(p)
+		if err == nil {
+			return nil
+		}
+		if !p.FallbackOnError && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) fetch(ctx context.Context, p Proxy, path, file string) ([]byte, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return nil, err
+	}
+	u := p.URL + "/" + escaped + "/" + file
+
+	if strings.HasPrefix(u, "file://") {
+		return readFile(u)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", u, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return data, nil
+	}
+
+	err = fmt.Errorf("reading %s: %s", u, resp.Status)
+	if msg, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n"); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		err = &notExistError{err}
+	}
+	return nil, err
+}
+
+func readFile(fileURL string) ([]byte, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	name := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir has the path "/C:/dir".
+		name = strings.TrimPrefix(name, "/")
+	}
+	// Errors from os.ReadFile already match fs.ErrNotExist for missing files.
+	return os.ReadFile(filepath.FromSlash(name))
+}
+
+// notExistError marks proxy responses after which the next
+// entry of a comma-separated GOPROXY list is tried.
+type notExistError struct {
+	err error
+}
+
+func (e *notExistError) Error() string { return e.err.Error() }
+
+func (e *notExistError) Is(target error) bool { return target == fs.ErrNotExist }
+
+func (e *notExistError) Unwrap() error { return e.err }
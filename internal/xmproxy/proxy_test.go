@@ -0,0 +1,301 @@
+package xmproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		goproxy string
+		want    []Proxy
+		wantErr bool
+	}{
+		{
+			goproxy: "https://proxy.golang.org,direct",
+			want:    []Proxy{{URL: "https://proxy.golang.org"}, {URL: "direct"}},
+		},
+		{
+			goproxy: "proxy.example.com/|direct",
+			want:    []Proxy{{URL: "https://proxy.example.com", FallbackOnError: true}, {URL: "direct"}},
+		},
+		{
+			goproxy: " http://a ,, file:///tmp/mods|off",
+			want: []Proxy{
+				{URL: "http://a"},
+				{URL: "file:///tmp/mods", FallbackOnError: true},
+				{URL: "off"},
+			},
+		},
+		{goproxy: "off", want: []Proxy{{URL: "off"}}},
+		{goproxy: "", wantErr: true},
+		{goproxy: " , ", wantErr: true},
+		{goproxy: "ftp://proxy.example.com", wantErr: true},
+		{goproxy: "http://[::1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseList(tt.goproxy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseList(%q) error = %v, want error %v", tt.goproxy, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseList(%q) = %v, want %v", tt.goproxy, got, tt.want)
+		}
+	}
+}
+
+// proxyServer serves the given files of the proxy protocol, and responds with
+// the status to the other requests. The paths of the requests are recorded.
+type proxyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newProxyServer(t *testing.T, status int, files map[string]string) *proxyServer {
+	s := &proxyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.mu.Unlock()
+		if data, ok := files[r.URL.Path]; ok {
+			fmt.Fprint(w, data)
+			return
+		}
+		http.Error(w, "no such thing", status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *proxyServer) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestFallback(t *testing.T) {
+	files := map[string]string{"/example.com/m/@v/list": "v1.0.0\nv1.2.0\nv1.1.0 extra\n"}
+	tests := []struct {
+		name   string
+		status int
+		sep    string
+		// reached reports whether the second proxy is queried.
+		reached bool
+	}{
+		{"not found after comma", http.StatusNotFound, ",", true},
+		{"gone after comma", http.StatusGone, ",", true},
+		{"server error after comma", http.StatusInternalServerError, ",", false},
+		{"forbidden after comma", http.StatusForbidden, ",", false},
+		{"not found after pipe", http.StatusNotFound, "|", true},
+		{"server error after pipe", http.StatusInternalServerError, "|", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := newProxyServer(t, tt.status, nil)
+			second := newProxyServer(t, http.StatusNotFound, files)
+			c, err := New(first.URL+tt.sep+second.URL, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions, err := c.Versions(context.Background(), "example.com/m")
+			if len(first.requested()) != 1 {
+				t.Errorf("first proxy requests = %q, want one", first.requested())
+			}
+			if reached := len(second.requested()) != 0; reached != tt.reached {
+				t.Fatalf("second proxy reached = %v, want %v", reached, tt.reached)
+			}
+			if !tt.reached {
+				if err == nil {
+					t.Fatal("Versions succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"v1.0.0", "v1.1.0", "v1.2.0"}; !reflect.DeepEqual(versions, want) {
+				t.Errorf("Versions = %q, want %q", versions, want)
+			}
+		})
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone, http.StatusInternalServerError} {
+		s := newProxyServer(t, status, nil)
+		c, err := New(s.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Info(context.Background(), "example.com/m", "v1.0.0")
+		if err == nil {
+			t.Fatalf("status %d: Info succeeded, want error", status)
+		}
+		notExist := status != http.StatusInternalServerError
+		if got := errors.Is(err, fs.ErrNotExist); got != notExist {
+			t.Errorf("status %d: errors.Is(%v, fs.ErrNotExist) = %v, want %v", status, err, got, notExist)
+		}
+		// The first line of the response body is kept in the message.
+		if !strings.Contains(err.Error(), "no such thing") {
+			t.Errorf("status %d: error %q does not include the response", status, err)
+		}
+	}
+}
+
+func TestOff(t *testing.T) {
+	s := newProxyServer(t, http.StatusNotFound, nil)
+	for _, goproxy := range []string{"off", s.URL + ",off"} {
+		c, err := New(goproxy, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Info(context.Background(), "example.com/m", "latest"); !errors.Is(err, errOff) {
+			t.Errorf("GOPROXY=%s: error = %v, want %v", goproxy, err, errOff)
+		}
+	}
+	if n := len(s.requested()); n != 1 {
+		t.Errorf("proxy requests = %d, want 1", n)
+	}
+}
+
+func TestDirect(t *testing.T) {
+	// Without the go command the direct lookups fail right away instead of
+	// reaching the version control systems.
+	t.Setenv("PATH", "")
+
+	s := newProxyServer(t, http.StatusNotFound, nil)
+	c, err := New(s.URL+",direct", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GoMod(context.Background(), "example.com/m", "v1.0.0"); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, exec.ErrNotFound)
+	}
+	if n := len(s.requested()); n != 1 {
+		t.Errorf("proxy requests = %d, want 1", n)
+	}
+
+	// The speculative queries do not go direct, unless it is the only entry.
+	if _, err := c.WithoutDirect().GoMod(context.Background(), "example.com/m", "v1.0.0"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("WithoutDirect error = %v, want %v", err, fs.ErrNotExist)
+	}
+	c, err = New("direct", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.WithoutDirect(); got != c {
+		t.Errorf("WithoutDirect of the direct only list = %v, want the same client", got.Proxies)
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	t.Setenv("PATH", "")
+	t.Setenv("GOENV", "off")
+	t.Setenv("GONOPROXY", "")
+	t.Setenv("GOPRIVATE", "example.com/private,*.corp.example")
+
+	s := newProxyServer(t, http.StatusNotFound, map[string]string{
+		"/example.com/public/@v/v1.0.0.mod": "module example.com/public\n",
+	})
+	t.Setenv("GOPROXY", s.URL)
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NoProxy != "example.com/private,*.corp.example" {
+		t.Fatalf("NoProxy = %q, want GOPRIVATE", c.NoProxy)
+	}
+
+	tests := []struct {
+		path   string
+		direct bool
+	}{
+		{"example.com/public", false},
+		{"example.com/private", true},
+		{"example.com/private/sub", true},
+		{"example.com/privateer", false},
+		{"git.corp.example/team/m", true},
+		{"corp.example/m", false},
+	}
+	for _, tt := range tests {
+		before := len(s.requested())
+		_, err := c.GoMod(context.Background(), tt.path, "v1.0.0")
+		proxied := len(s.requested()) != before
+		if proxied == tt.direct {
+			t.Errorf("%s: proxied = %v, want %v", tt.path, proxied, !tt.direct)
+		}
+		if direct := errors.Is(err, exec.ErrNotFound); direct != tt.direct {
+			t.Errorf("%s: error = %v, want direct %v", tt.path, err, tt.direct)
+		}
+	}
+
+	// GONOPROXY takes precedence over GOPRIVATE.
+	t.Setenv("GONOPROXY", "none.example")
+	c, err = FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NoProxy != "none.example" {
+		t.Errorf("NoProxy = %q, want GONOPROXY", c.NoProxy)
+	}
+}
+
+func TestEscapedPaths(t *testing.T) {
+	const path, version = "github.com/BurntSushi/toml", "v1.4.0-RC1"
+	const escaped = "/github.com/!burnt!sushi/toml/@v/v1.4.0-!r!c1"
+	const info = `{"Version":"v1.4.0-RC1","Time":"2024-05-01T10:00:00Z"}`
+
+	s := newProxyServer(t, http.StatusNotFound, map[string]string{escaped + ".info": info})
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, filepath.FromSlash(escaped+".info"))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	if !strings.HasPrefix(fileURL, "file:///") {
+		// Windows paths like C:/dir need the leading slash.
+		fileURL = "file:///" + strings.TrimPrefix(fileURL, "file://")
+	}
+
+	for _, goproxy := range []string{s.URL, fileURL} {
+		c, err := New(goproxy, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.Info(context.Background(), path, version)
+		if err != nil {
+			t.Fatalf("GOPROXY=%s: %v", goproxy, err)
+		}
+		if got.Version != version || got.Time.IsZero() {
+			t.Errorf("GOPROXY=%s: Info = %+v", goproxy, got)
+		}
+	}
+
+	// The missing files of the tree fall back like the 404 responses.
+	c, err := New(fileURL+",off", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GoMod(context.Background(), path, version); !errors.Is(err, errOff) {
+		t.Errorf("missing file: error = %v, want %v", err, errOff)
+	}
+}
@@ -0,0 +1,161 @@
+package xmreport
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Kinds of the dependency changes.
+const (
+	DepAdded      = "added"
+	DepRemoved    = "removed"
+	DepUpgraded   = "upgraded"
+	DepDowngraded = "downgraded"
+	// DepChanged is a change between versions which cannot be ordered, or of the module hash.
+	DepChanged  = "changed"
+	DepReplaced = "replaced"
+)
+
+// Diff describes the changes between two builds of a binary.
+type Diff struct {
+	Old       string  `json:"old"`
+	New       string  `json:"new"`
+	GoVersion *Change `json:"go_version,omitempty"`
+	// Path is the change of the main package path.
+	Path *Change `json:"path,omitempty"`
+	// Main is the change of the main module version.
+	Main     *Change          `json:"main,omitempty"`
+	Deps     []*DepChange     `json:"deps,omitempty"`
+	Settings []*SettingChange `json:"settings,omitempty"`
+}
+
+func (*Diff) kind() string { return "diff" }
+
+// Empty reports whether the builds are the same.
+func (d *Diff) Empty() bool {
+	return d.GoVersion == nil && d.Path == nil && d.Main == nil && len(d.Deps) == 0 && len(d.Settings) == 0
+}
+
+// Change is a value which differs between the old and the new builds.
+// An empty value means it is absent.
+type Change struct {
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+func newChange(old, new string) *Change {
+	if old == new {
+		return nil
+	}
+	return &Change{Old: old, New: new}
+}
+
+type DepChange struct {
+	Path string `json:"path"`
+	// Kind is one of the Dep* constants.
+	Kind    string  `json:"kind"`
+	Version *Change `json:"version,omitempty"`
+	// Replace is the change of the replacement module, "path version" or "path" of a local directory.
+	Replace *Change `json:"replace,omitempty"`
+}
+
+type SettingChange struct {
+	Key string `json:"key"`
+	Change
+}
+
+// Compare returns the changes between the old and the new builds. The names
+// identify the builds in the output, e.g. the file names.
+func Compare(oldName string, old *Binary, newName string, new *Binary) *Diff {
+	d := &Diff{
+		Old:       oldName,
+		New:       newName,
+		GoVersion: newChange(old.GoVersion, new.GoVersion),
+		Path:      newChange(old.Path, new.Path),
+		Main:      newChange(old.Main.Version, new.Main.Version),
+		Deps:      compareDeps(old.Deps, new.Deps),
+	}
+
+	for _, s := range old.Settings {
+		v, _ := new.Setting(s.Key)
+		if c := newChange(s.Value, v); c != nil {
+			d.Settings = append(d.Settings, &SettingChange{Key: s.Key, Change: *c})
+		}
+	}
+	for _, s := range new.Settings {
+		if _, ok := old.Setting(s.Key); !ok && s.Value != "" {
+			d.Settings = append(d.Settings, &SettingChange{Key: s.Key, Change: Change{New: s.Value}})
+		}
+	}
+	return d
+}
+
+// CompareModules returns the changes between the old and the new go.mod files.
+func CompareModules(oldName string, old *GoMod, newName string, new *GoMod) *Diff {
+	return &Diff{
+		Old:       oldName,
+		New:       newName,
+		GoVersion: newChange(old.GoVersion, new.GoVersion),
+		Path:      newChange(old.Path, new.Path),
+		Deps:      compareDeps(requiredModules(old), requiredModules(new)),
+	}
+}
+
+func compareDeps(old, new []*Module) []*DepChange {
+	oldMods := map[string]*Module{}
+	for _, m := range old {
+		oldMods[m.Path] = m
+	}
+	newMods := map[string]*Module{}
+	for _, m := range new {
+		newMods[m.Path] = m
+	}
+
+	var changes []*DepChange
+	for _, m := range old {
+		if newMods[m.Path] == nil {
+			changes = append(changes, &DepChange{Path: m.Path, Kind: DepRemoved, Version: newChange(m.Version, "")})
+		}
+	}
+	for _, n := range new {
+		o := oldMods[n.Path]
+		if o == nil {
+			changes = append(changes, &DepChange{Path: n.Path, Kind: DepAdded, Version: newChange("", n.Version)})
+			continue
+		}
+		c := &DepChange{
+			Path:    n.Path,
+			Version: newChange(o.Version, n.Version),
+			Replace: newChange(replacement(o), replacement(n)),
+		}
+		switch {
+		case c.Version != nil && semver.IsValid(o.Version) && semver.IsValid(n.Version):
+			c.Kind = DepUpgraded
+			if semver.Compare(o.Version, n.Version) > 0 {
+				c.Kind = DepDowngraded
+			}
+		case c.Version != nil:
+			c.Kind = DepChanged
+		case c.Replace != nil:
+			c.Kind = DepReplaced
+		case o.Sum != n.Sum:
+			c.Kind = DepChanged
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	slices.SortStableFunc(changes, func(a, b *DepChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+func replacement(m *Module) string {
+	if m.Replace == nil {
+		return ""
+	}
+	return strings.TrimSpace(m.Replace.Path + " " + m.Replace.Version)
+}
@@ -0,0 +1,391 @@
+package xmreport
+
+import (
+	"debug/buildinfo"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Schema identifies the version of the structured output format.
+// It is bumped whenever a field is removed or changes its meaning.
+const Schema = "goxm/v1"
+
+// Target is a single examined artifact: a binary, a process or a go.mod file.
+type Target interface {
+	kind() string
+}
+
+type Binary struct {
+	File      string    `json:"file"`
+	Path      string    `json:"path"`
+	GoVersion string    `json:"go_version"`
+	Main      *Module   `json:"main"`
+	Deps      []*Module `json:"deps"`
+	Settings  []Setting `json:"settings"`
+	// Size and ModTime describe the file, they are unset if it cannot be examined.
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"mod_time,omitempty"`
+	Summary *Summary   `json:"summary,omitempty"`
+	// Stdlib is the standard library the binary was built with. It is set
+	// when the binary is checked for vulnerabilities.
+	Stdlib *Module `json:"stdlib,omitempty"`
+	// Violations are the violated rules of the policy the binary is checked against.
+	Violations []*Violation `json:"violations,omitempty"`
+	// Image and Layer are set for the binaries found in container images: the name of
+	// the image and the digest of the layer the file comes from. File is the path
+	// in the file system of the image then.
+	Image string `json:"image,omitempty"`
+	Layer string `json:"layer,omitempty"`
+	// Archive is set for the binaries found in archives, packages and images: the file
+	// they are read from. File is the path of the entry in the archive then.
+	Archive string `json:"archive,omitempty"`
+	// Packages are the Go packages linked into the binary, read from its symbol table
+	// when asked for. PackagesError tells why they could not be read.
+	Packages      []*Package `json:"packages,omitempty"`
+	PackagesError string     `json:"packages_error,omitempty"`
+	// Hardening is read from the executable when asked for, HardeningError tells why
+	// it could not be read.
+	Hardening      *Hardening `json:"hardening,omitempty"`
+	HardeningError string     `json:"hardening_error,omitempty"`
+}
+
+func (*Binary) kind() string { return "binary" }
+
+type Package struct {
+	Path string `json:"path"`
+	// Module is the path of the module providing the package, StdModule for the standard library.
+	Module string `json:"module,omitempty"`
+	// Files are the source files of the package functions compiled into the binary.
+	Files []string `json:"files,omitempty"`
+}
+
+// Hardening describes the security properties of the executable. The properties which
+// do not apply to its format are false.
+type Hardening struct {
+	// Format is "elf", "pe" or "macho".
+	Format string `json:"format"`
+	// BuildMode and Trimpath come from the build settings, the build mode is "exe" by default.
+	BuildMode string `json:"buildmode"`
+	Trimpath  bool   `json:"trimpath"`
+	// PIE is set for the position independent executables: ELF of type ET_DYN,
+	// PE with DYNAMIC_BASE and relocations, Mach-O with the MH_PIE flag.
+	PIE bool `json:"pie"`
+	// RELRO and BindNow are set for ELF with the GNU_RELRO segment and with the
+	// relocations resolved at startup, which together make the full RELRO.
+	RELRO   bool `json:"relro"`
+	BindNow bool `json:"bind_now"`
+	// NXStack is set if the stack is not executable: ELF with a GNU_STACK segment
+	// without the execute permission, PE with NX_COMPAT, Mach-O without the
+	// MH_ALLOW_STACK_EXECUTION flag.
+	NXStack bool `json:"nx_stack"`
+	Symtab  bool `json:"symtab"`
+	DWARF   bool `json:"dwarf"`
+	// Static is set if the executable needs neither a dynamic loader nor shared libraries.
+	Static bool `json:"static"`
+	// Interpreter is the dynamic loader, e.g. "/lib64/ld-linux-x86-64.so.2".
+	Interpreter string `json:"interpreter,omitempty"`
+	// Needed are the shared libraries the executable is linked with.
+	Needed []string `json:"needed,omitempty"`
+	// Arches are the properties of every architecture of a universal Mach-O binary,
+	// named by Arch. The properties of the binary hold for all of them then.
+	Arches []*Hardening `json:"arches,omitempty"`
+	Arch   string       `json:"arch,omitempty"`
+}
+
+// StdModule is the module of the standard library packages.
+const StdModule = "std"
+
+type Module struct {
+	Path    string  `json:"path"`
+	Version string  `json:"version,omitempty"`
+	Sum     string  `json:"sum,omitempty"`
+	Replace *Module `json:"replace,omitempty"`
+	Latest  *Latest `json:"latest,omitempty"`
+	// Major is the latest version of the highest major version above the current one,
+	// e.g. the latest version of "example.com/mod/v3" for "example.com/mod".
+	Major *Latest `json:"major,omitempty"`
+	// Deprecated is the deprecation message from the go.mod file of the latest version.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Retracted is set when the latest go.mod file retracts the current version.
+	Retracted *Retraction `json:"retracted,omitempty"`
+	// Time is when the current version was published.
+	Time *time.Time `json:"time,omitempty"`
+	// Update is the kind of the update to the Latest version: "patch", "minor", "major"
+	// or "pseudo". It is "major" only for the +incompatible versions, the upgrades to the
+	// modules of the higher major versions are in Major.
+	Update string `json:"update,omitempty"`
+	// DaysBehind is the number of days between the current and the latest versions.
+	DaysBehind int `json:"days_behind,omitempty"`
+	// Commit is set for pseudo-versions and for the "(devel)" main module.
+	Commit *Commit `json:"commit,omitempty"`
+	// NewerRelease is the latest tagged version if it was published after the Commit.
+	NewerRelease string `json:"newer_release,omitempty"`
+	// Vulns are the known vulnerabilities affecting the current version.
+	Vulns []*Vuln `json:"vulns,omitempty"`
+}
+
+type Violation struct {
+	Rule string `json:"rule"`
+	// Module is the path of the violating module, empty for the rules on the target itself.
+	Module  string `json:"module,omitempty"`
+	Message string `json:"message"`
+}
+
+type Vuln struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	// Fixed is the lowest version fixing the vulnerability, empty if there is no fix.
+	Fixed string `json:"fixed,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Commit identifies the commit a module was built from. It is decoded from
+// a pseudo-version, or taken from the VCS build settings of a "(devel)" binary.
+type Commit struct {
+	// Base is the tag a pseudo-version is based on, empty if there is no such tag.
+	Base     string    `json:"base,omitempty"`
+	Revision string    `json:"revision"`
+	Time     time.Time `json:"time"`
+	Modified bool      `json:"modified,omitempty"`
+}
+
+type Retraction struct {
+	Rationale string `json:"rationale,omitempty"`
+}
+
+type Latest struct {
+	// Path is set for major versions, whose module paths differ.
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Offline is set when the version is the latest one found in the local module cache.
+	Offline bool `json:"offline,omitempty"`
+	// Time is when the version was published.
+	Time *time.Time `json:"time,omitempty"`
+}
+
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type Process struct {
+	PID  int32  `json:"pid"`
+	Name string `json:"name"`
+	*Binary
+	Connections []Connection `json:"connections,omitempty"`
+	ConnError   string       `json:"connections_error,omitempty"`
+}
+
+func (*Process) kind() string { return "process" }
+
+type Connection struct {
+	Family string `json:"family"`
+	Local  Addr   `json:"local"`
+	Remote Addr   `json:"remote"`
+	Status string `json:"status,omitempty"`
+}
+
+type Addr struct {
+	IP   string `json:"ip"`
+	Port uint32 `json:"port"`
+}
+
+type GoMod struct {
+	File      string     `json:"file"`
+	Path      string     `json:"path,omitempty"`
+	GoVersion string     `json:"go_version,omitempty"`
+	Requires  []*Require `json:"requires,omitempty"`
+	Summary   *Summary   `json:"summary,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	// Violations are the violated rules of the policy the go.mod file is checked against.
+	Violations []*Violation `json:"violations,omitempty"`
+}
+
+func (*GoMod) kind() string { return "module" }
+
+type Require struct {
+	*Module
+	Indirect bool `json:"indirect,omitempty"`
+	// Line is the line of the requirement in the go.mod file.
+	Line int `json:"line,omitempty"`
+}
+
+// HasUpdate reports whether the module has an update of the kind, which is "major"
+// for the upgrades to the higher major versions too.
+func (m *Module) HasUpdate(kind string) bool {
+	return m.Update == kind || kind == "major" && m.Major != nil
+}
+
+// Outdated reports whether the module has any update or major upgrade.
+func (m *Module) Outdated() bool {
+	return m.Update != "" || m.Major != nil
+}
+
+// Summary describes how outdated the dependencies of a target are.
+type Summary struct {
+	Modules  int `json:"modules"`
+	Outdated int `json:"outdated"`
+	// Updates is the number of outdated modules by the kind of the update. A module
+	// with both an update and a major upgrade is counted for both kinds.
+	Updates map[string]int `json:"updates,omitempty"`
+	// Libyear is the sum of the times between the current and the latest versions, in years.
+	Libyear float64 `json:"libyear"`
+}
+
+func Summarize(mods []*Module) *Summary {
+	s := &Summary{Modules: len(mods), Updates: map[string]int{}}
+	days := 0
+	for _, m := range mods {
+		if m.Outdated() {
+			s.Outdated++
+		}
+		for _, kind := range []string{"patch", "minor", "major", "pseudo"} {
+			if m.HasUpdate(kind) {
+				s.Updates[kind]++
+			}
+		}
+		days += m.DaysBehind
+	}
+	s.Libyear = float64(days) / 365
+	return s
+}
+
+// NewBinary converts the build information read from the file into a Binary.
+// The modification time of the file is zero if it is unknown.
+func NewBinary(file string, size int64, mtime time.Time, info *buildinfo.BuildInfo) *Binary {
+	b := &Binary{
+		File:      file,
+		Path:      info.Path,
+		GoVersion: info.GoVersion,
+		Main:      newModule(&info.Main),
+		Deps:      make([]*Module, 0, len(info.Deps)),
+		Settings:  make([]Setting, 0, len(info.Settings)),
+	}
+	for _, d := range info.Deps {
+		b.Deps = append(b.Deps, newModule(d))
+	}
+	for _, s := range info.Settings {
+		b.Settings = append(b.Settings, Setting{Key: s.Key, Value: s.Value})
+	}
+	if b.Main.Commit == nil {
+		b.Main.Commit = vcsCommit(b.Settings)
+	}
+	b.Size = size
+	if !mtime.IsZero() {
+		b.ModTime = &mtime
+	}
+	return b
+}
+
+// Setting returns the value of the build setting with the given key.
+func (b *Binary) Setting(key string) (string, bool) {
+	for _, s := range b.Settings {
+		if s.Key == key {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// ModuleOf returns the path of the module providing the package: the main module or
+// the dependency with the longest matching path, StdModule for the standard library,
+// or "" if it is unknown.
+func (b *Binary) ModuleOf(pkg string) string {
+	if pkg == "main" {
+		return b.Main.Path
+	}
+	best := ""
+	for _, m := range append([]*Module{b.Main}, b.Deps...) {
+		if m.Path != "" && len(m.Path) > len(best) && (pkg == m.Path || strings.HasPrefix(pkg, m.Path+"/")) {
+			best = m.Path
+		}
+	}
+	if best == "" && !strings.Contains(strings.Split(pkg, "/")[0], ".") {
+		return StdModule
+	}
+	return best
+}
+
+func newModule(m *debug.Module) *Module {
+	if m == nil {
+		return nil
+	}
+	return &Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+		Replace: newModule(m.Replace),
+		Commit:  pseudoCommit(m.Version),
+	}
+}
+
+func pseudoCommit(version string) *Commit {
+	if !module.IsPseudoVersion(version) {
+		return nil
+	}
+	c := &Commit{}
+	c.Base, _ = module.PseudoVersionBase(version)
+	c.Revision, _ = module.PseudoVersionRev(version)
+	c.Time, _ = module.PseudoVersionTime(version)
+	return c
+}
+
+func vcsCommit(settings []Setting) *Commit {
+	c := &Commit{}
+	for _, s := range settings {
+		switch s.Key {
+		case "vcs.revision":
+			c.Revision = s.Value
+		case "vcs.time":
+			c.Time, _ = time.Parse(time.RFC3339, s.Value)
+		case "vcs.modified":
+			c.Modified = s.Value == "true"
+		}
+	}
+	if c.Revision == "" {
+		return nil
+	}
+	return c
+}
+
+// NewGoMod converts the parsed go.mod file into a GoMod.
+func NewGoMod(file string, f *modfile.File) *GoMod {
+	m := &GoMod{
+		File:     file,
+		Requires: make([]*Require, 0, len(f.Require)),
+	}
+	if f.Module != nil {
+		m.Path = f.Module.Mod.Path
+	}
+	if f.Go != nil {
+		m.GoVersion = f.Go.Version
+	}
+	for _, r := range f.Require {
+		req := &Require{
+			Module: &Module{
+				Path:    r.Mod.Path,
+				Version: r.Mod.Version,
+				Commit:  pseudoCommit(r.Mod.Version),
+			},
+			Indirect: r.Indirect,
+		}
+		if r.Syntax != nil {
+			req.Line = r.Syntax.Start.Line
+		}
+		m.Requires = append(m.Requires, req)
+	}
+	for _, r := range f.Replace {
+		for _, req := range m.Requires {
+			if req.Path == r.Old.Path && (r.Old.Version == "" || req.Version == r.Old.Version) {
+				req.Replace = &Module{Path: r.New.Path, Version: r.New.Version}
+			}
+		}
+	}
+	return m
+}
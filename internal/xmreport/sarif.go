@@ -0,0 +1,226 @@
+package xmreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Rules of the SARIF results, the vulnerabilities use their IDs as the rules.
+const (
+	ruleOutdated   = "goxm/outdated"
+	ruleRetracted  = "goxm/retracted"
+	ruleDeprecated = "goxm/deprecated"
+	rulePolicy     = "goxm/policy/"
+)
+
+var sarifRules = map[string]string{
+	ruleOutdated:   "A newer version of the module is available",
+	ruleRetracted:  "The module version is retracted",
+	ruleDeprecated: "The module is deprecated",
+}
+
+// sarifLog is a SARIF 2.1.0 document, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name           string       `json:"name"`
+			InformationURI string       `json:"informationUri"`
+			Rules          []*sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifWriter reports the findings about the modules: available updates, retractions,
+// deprecations, vulnerabilities and policy violations. The results point at the lines
+// of the go.mod files, or at the binary files.
+type sarifWriter struct {
+	w       io.Writer
+	rules   map[string]*sarifRule
+	results []*sarifResult
+}
+
+func (s *sarifWriter) Write(t Target) error {
+	switch t := t.(type) {
+	case *Binary:
+		s.binary(t)
+	case *Process:
+		s.binary(t.Binary)
+	case *GoMod:
+		lines := map[string]int{}
+		for _, r := range t.Requires {
+			lines[r.Path] = r.Line
+			s.module(r.Module, sarifTarget{file: t.File, line: r.Line})
+		}
+		for _, v := range t.Violations {
+			s.violation(v, sarifTarget{file: t.File, line: lines[v.Module]})
+		}
+	default:
+		return fmt.Errorf("%w: %T", errNoFindings, t)
+	}
+	return nil
+}
+
+// sarifTarget is what the results point at: the line of the file, or the entry of the
+// binary in the file if it is an archive or an image, which is named in the messages.
+type sarifTarget struct {
+	file  string
+	line  int
+	entry string
+}
+
+func (s *sarifWriter) binary(b *Binary) {
+	at := sarifTarget{file: b.File}
+	if b.Archive != "" {
+		at = sarifTarget{file: b.Archive, entry: b.File}
+		if b.Image != "" {
+			at.entry = b.Image + " " + b.File
+		}
+	}
+	for _, m := range append([]*Module{b.Stdlib, b.Main}, b.Deps...) {
+		if m != nil {
+			s.module(m, at)
+		}
+	}
+	for _, v := range b.Violations {
+		s.violation(v, at)
+	}
+}
+
+func (s *sarifWriter) module(m *Module, at sarifTarget) {
+	if m.Update != "" && m.Latest != nil {
+		s.add(ruleOutdated, "note", fmt.Sprintf("%s %s can be updated to %s (%s)", m.Path, m.Version, m.Latest.Version, m.Update), at)
+	}
+	if m.Major != nil {
+		s.add(ruleOutdated, "note", fmt.Sprintf("%s %s can be upgraded to %s %s (major)", m.Path, m.Version, m.Major.Path, m.Major.Version), at)
+	}
+	if m.Retracted != nil {
+		msg := fmt.Sprintf("%s %s is retracted", m.Path, m.Version)
+		if m.Retracted.Rationale != "" {
+			msg += ": " + m.Retracted.Rationale
+		}
+		s.add(ruleRetracted, "warning", msg, at)
+	}
+	if m.Deprecated != "" {
+		s.add(ruleDeprecated, "warning", fmt.Sprintf("%s is deprecated: %s", m.Path, m.Deprecated), at)
+	}
+	for _, v := range m.Vulns {
+		if s.rules[v.ID] == nil {
+			s.rules[v.ID] = &sarifRule{ID: v.ID, ShortDescription: sarifMessage{v.Summary}, HelpURI: v.URL}
+		}
+		msg := fmt.Sprintf("%s %s is affected by %s", m.Path, m.Version, v.ID)
+		if v.Fixed != "" {
+			msg += ", fixed in " + v.Fixed
+		}
+		s.add(v.ID, "error", msg+": "+v.Summary, at)
+	}
+}
+
+func (s *sarifWriter) violation(v *Violation, at sarifTarget) {
+	id := rulePolicy + v.Rule
+	if s.rules[id] == nil {
+		s.rules[id] = &sarifRule{ID: id, ShortDescription: sarifMessage{"Violation of the " + v.Rule + " policy rule"}}
+	}
+	s.add(id, "error", v.Message, at)
+}
+
+func (s *sarifWriter) add(rule, level, msg string, at sarifTarget) {
+	if s.rules[rule] == nil {
+		s.rules[rule] = &sarifRule{ID: rule, ShortDescription: sarifMessage{sarifRules[rule]}}
+	}
+	if at.entry != "" {
+		msg = at.entry + ": " + msg
+	}
+	var loc sarifLocation
+	loc.PhysicalLocation.ArtifactLocation.URI = artifactURI(at.file)
+	if at.line != 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: at.line}
+	}
+	s.results = append(s.results, &sarifResult{
+		RuleID:    rule,
+		Level:     level,
+		Message:   sarifMessage{msg},
+		Locations: []sarifLocation{loc},
+	})
+}
+
+// artifactURI returns the URI of the file. Files in the working directory get relative
+// URIs, so that code scanning can resolve them against the repository root.
+func artifactURI(file string) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(wd, file); err == nil && filepath.IsLocal(rel) {
+			file = rel
+		}
+	}
+	uri := filepath.ToSlash(file)
+	if filepath.IsAbs(file) {
+		if !strings.HasPrefix(uri, "/") {
+			uri = "/" + uri
+		}
+		return "file://" + uri
+	}
+	return uri
+}
+
+func (s *sarifWriter) Close() error {
+	run := sarifRun{Results: s.results}
+	run.Tool.Driver.Name = "goxm"
+	run.Tool.Driver.InformationURI = "https://github.com/o7q2ab/goxm"
+	run.Tool.Driver.Rules = []*sarifRule{}
+	for _, id := range slices.Sorted(maps.Keys(s.rules)) {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, s.rules[id])
+	}
+	if run.Results == nil {
+		run.Results = []*sarifResult{}
+	}
+
+	enc := json.NewEncoder(s.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
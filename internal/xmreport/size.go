@@ -0,0 +1,161 @@
+package xmreport
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/o7q2ab/goxm/internal/xmsym"
+)
+
+// Size attributes the bytes of the sections of a Go binary to its packages and modules.
+type Size struct {
+	File string `json:"file"`
+	// Total is the size of the file.
+	Total int64 `json:"total"`
+	// Sections are the sizes of the text, rodata and data sections.
+	Sections map[string]int64 `json:"sections"`
+	// Stripped is set if the binary has no symbol table, only the text and the pclntab
+	// of the functions are attributed then.
+	Stripped bool       `json:"stripped,omitempty"`
+	Shares   *SizeShare `json:"shares"`
+	// Modules and Packages are ordered by their sizes, the largest first.
+	Modules  []*SizeEntry `json:"modules"`
+	Packages []*SizeEntry `json:"packages"`
+}
+
+func (*Size) kind() string { return "size" }
+
+// SizeShare splits the bytes of the sections by where they come from.
+type SizeShare struct {
+	Std  int64 `json:"std"`
+	Main int64 `json:"main"`
+	Deps int64 `json:"deps"`
+	// Other are the bytes of the packages of unknown modules.
+	Other int64 `json:"other,omitempty"`
+	// Unattributed are the bytes not taken by the symbols of any package, e.g. the
+	// string literals and the file tables of the pclntab.
+	Unattributed int64 `json:"unattributed"`
+}
+
+type SizeEntry struct {
+	// Name is the path of the package or the module, StdModule for the standard library.
+	Name string `json:"name"`
+	// Module is the path of the module of a package, "" if it is unknown.
+	Module string `json:"module,omitempty"`
+	// Version is the version of a module.
+	Version string `json:"version,omitempty"`
+	Size    int64  `json:"size"`
+	// Sections are the bytes taken in the sections by their kinds.
+	Sections map[string]int64 `json:"sections"`
+}
+
+func (e *SizeEntry) add(kind string, n int64) {
+	e.Size += n
+	e.Sections[kind] += n
+}
+
+// NewSize attributes the symbols of the binary to its packages and modules.
+func NewSize(b *Binary, l *xmsym.Layout) *Size {
+	s := &Size{File: b.File, Total: b.Size, Sections: l.Sections, Stripped: l.Stripped, Shares: &SizeShare{}}
+	versions := map[string]string{b.Main.Path: b.Main.Version}
+	for _, d := range b.Deps {
+		versions[d.Path] = d.Version
+	}
+
+	pkgs := map[string]*SizeEntry{}
+	mods := map[string]*SizeEntry{}
+	var attributed int64
+	for _, sym := range l.Symbols {
+		path := sym.Package()
+		if path == "" || sym.Size == 0 {
+			continue
+		}
+		p, ok := pkgs[path]
+		if !ok {
+			p = &SizeEntry{Name: path, Module: b.ModuleOf(path), Sections: map[string]int64{}}
+			pkgs[path] = p
+		}
+		m, ok := mods[p.Module]
+		if !ok {
+			m = &SizeEntry{Name: p.Module, Version: versions[p.Module], Sections: map[string]int64{}}
+			mods[p.Module] = m
+		}
+		p.add(sym.Kind, sym.Size)
+		m.add(sym.Kind, sym.Size)
+		attributed += sym.Size
+
+		switch {
+		case p.Module == StdModule:
+			s.Shares.Std += sym.Size
+		case p.Module == "":
+			s.Shares.Other += sym.Size
+		case p.Module == b.Main.Path:
+			s.Shares.Main += sym.Size
+		default:
+			s.Shares.Deps += sym.Size
+		}
+	}
+	var sections int64
+	for _, n := range l.Sections {
+		sections += n
+	}
+	s.Shares.Unattributed = max(sections-attributed, 0)
+
+	s.Packages = sortedEntries(pkgs)
+	s.Modules = sortedEntries(mods)
+	return s
+}
+
+func sortedEntries(m map[string]*SizeEntry) []*SizeEntry {
+	entries := make([]*SizeEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b *SizeEntry) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.Name, b.Name))
+	})
+	return entries
+}
+
+// SectionsSize returns the total size of the sections.
+func (s *Size) SectionsSize() int64 {
+	var n int64
+	for _, v := range s.Sections {
+		n += v
+	}
+	return n
+}
+
+// TreeNode is a node of the tree of the sizes, in the format taken by the treemap
+// charts, e.g. of D3 and ECharts.
+type TreeNode struct {
+	Name     string      `json:"name"`
+	Value    int64       `json:"value"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// Tree returns the sizes as the tree of the modules and their packages, with a leaf
+// for the unattributed bytes.
+func (s *Size) Tree() *TreeNode {
+	root := &TreeNode{Name: s.File}
+	mods := map[string]*TreeNode{}
+	for _, m := range s.Modules {
+		name := m.Name
+		if name == "" {
+			name = "(unknown module)"
+		}
+		mods[m.Name] = &TreeNode{Name: name, Value: m.Size}
+		root.Children = append(root.Children, mods[m.Name])
+	}
+	for _, p := range s.Packages {
+		m := mods[p.Module]
+		m.Children = append(m.Children, &TreeNode{Name: p.Name, Value: p.Size})
+	}
+	if s.Shares.Unattributed != 0 {
+		root.Children = append(root.Children, &TreeNode{Name: "(unattributed)", Value: s.Shares.Unattributed})
+	}
+	for _, c := range root.Children {
+		root.Value += c.Value
+	}
+	return root
+}
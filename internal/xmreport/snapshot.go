@@ -0,0 +1,142 @@
+package xmreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ReadReport reads a document written by the "json" output format, e.g. a snapshot.
+func ReadReport(r io.Reader) (*Report, error) {
+	report := &Report{}
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, err
+	}
+	if report.Schema != Schema {
+		return nil, fmt.Errorf("%w: %q", errUnknownSchema, report.Schema)
+	}
+	return report, nil
+}
+
+// Drift describes the changes between two inventories of a host.
+type Drift struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+
+	AddedBinaries   []*Item `json:"added_binaries,omitempty"`
+	RemovedBinaries []*Item `json:"removed_binaries,omitempty"`
+	ChangedBinaries []*Diff `json:"changed_binaries,omitempty"`
+
+	AddedProcesses   []*Item `json:"added_processes,omitempty"`
+	RemovedProcesses []*Item `json:"removed_processes,omitempty"`
+	ChangedProcesses []*Diff `json:"changed_processes,omitempty"`
+
+	AddedModules   []*Item `json:"added_modules,omitempty"`
+	RemovedModules []*Item `json:"removed_modules,omitempty"`
+	ChangedModules []*Diff `json:"changed_modules,omitempty"`
+}
+
+func (*Drift) kind() string { return "drift" }
+
+// Empty reports whether the inventories are the same.
+func (d *Drift) Empty() bool {
+	return len(d.AddedBinaries)+len(d.RemovedBinaries)+len(d.ChangedBinaries)+
+		len(d.AddedProcesses)+len(d.RemovedProcesses)+len(d.ChangedProcesses)+
+		len(d.AddedModules)+len(d.RemovedModules)+len(d.ChangedModules) == 0
+}
+
+// Item identifies a binary, a process or a go.mod file of an inventory.
+type Item struct {
+	File      string `json:"file"`
+	PID       int32  `json:"pid,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+func binaryItem(b *Binary) *Item {
+	return &Item{File: b.File, Path: b.Path, Version: b.Main.Version, GoVersion: b.GoVersion}
+}
+
+// CompareReports returns the changes between the old and the new inventories.
+// Binaries and go.mod files are matched by their file names, processes by their executables.
+func CompareReports(oldName string, old *Report, newName string, new *Report) *Drift {
+	d := &Drift{Old: oldName, New: newName}
+
+	oldBins := byFile(old.Binaries, func(b *Binary) string { return b.File })
+	for _, b := range new.Binaries {
+		o, ok := oldBins[b.File]
+		if !ok {
+			d.AddedBinaries = append(d.AddedBinaries, binaryItem(b))
+		} else if diff := Compare(o.File, o, b.File, b); !diff.Empty() {
+			d.ChangedBinaries = append(d.ChangedBinaries, diff)
+		}
+	}
+	newBins := byFile(new.Binaries, func(b *Binary) string { return b.File })
+	for _, b := range old.Binaries {
+		if _, ok := newBins[b.File]; !ok {
+			d.RemovedBinaries = append(d.RemovedBinaries, binaryItem(b))
+		}
+	}
+
+	procItem := func(p *Process) *Item {
+		item := binaryItem(p.Binary)
+		item.PID, item.Name = p.PID, p.Name
+		return item
+	}
+	oldProcs := byFile(old.Processes, func(p *Process) string { return p.File })
+	for _, p := range uniqueByFile(new.Processes) {
+		o, ok := oldProcs[p.File]
+		if !ok {
+			d.AddedProcesses = append(d.AddedProcesses, procItem(p))
+		} else if diff := Compare(o.File, o.Binary, p.File, p.Binary); !diff.Empty() {
+			d.ChangedProcesses = append(d.ChangedProcesses, diff)
+		}
+	}
+	newProcs := byFile(new.Processes, func(p *Process) string { return p.File })
+	for _, p := range uniqueByFile(old.Processes) {
+		if _, ok := newProcs[p.File]; !ok {
+			d.RemovedProcesses = append(d.RemovedProcesses, procItem(p))
+		}
+	}
+
+	modItem := func(m *GoMod) *Item {
+		return &Item{File: m.File, Path: m.Path, GoVersion: m.GoVersion}
+	}
+	oldMods := byFile(old.Modules, func(m *GoMod) string { return m.File })
+	for _, m := range new.Modules {
+		o, ok := oldMods[m.File]
+		if !ok {
+			d.AddedModules = append(d.AddedModules, modItem(m))
+		} else if diff := CompareModules(o.File, o, m.File, m); !diff.Empty() {
+			d.ChangedModules = append(d.ChangedModules, diff)
+		}
+	}
+	newMods := byFile(new.Modules, func(m *GoMod) string { return m.File })
+	for _, m := range old.Modules {
+		if _, ok := newMods[m.File]; !ok {
+			d.RemovedModules = append(d.RemovedModules, modItem(m))
+		}
+	}
+	return d
+}
+
+func byFile[T any](items []T, file func(T) string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		if _, ok := m[file(item)]; !ok {
+			m[file(item)] = item
+		}
+	}
+	return m
+}
+
+// uniqueByFile returns the first process of each executable, sorted by the executable.
+func uniqueByFile(procs []*Process) []*Process {
+	unique := slices.Clone(procs)
+	slices.SortStableFunc(unique, func(a, b *Process) int { return strings.Compare(a.File, b.File) })
+	return slices.CompactFunc(unique, func(a, b *Process) bool { return a.File == b.File })
+}
@@ -0,0 +1,333 @@
+package xmreport
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	errUnknownColumn = errors.New("unknown table column")
+	errUnknownGroup  = errors.New("unknown table grouping")
+)
+
+// Table describes the table view of the text output format.
+type Table struct {
+	// Columns are the names of the columns, the defaults of the command are used if empty.
+	Columns []string
+	// Sort is the name of the column to sort the rows by, prefixed with "-" for descending order.
+	Sort string
+	// GroupBy is "module" or "go".
+	GroupBy string
+}
+
+type column struct {
+	name  string
+	value func(Target) string
+	// compare orders the rows, comparing the values as strings if nil.
+	compare func(a, b Target) int
+}
+
+// TableColumns are the names of the available table columns.
+var TableColumns = []string{
+	"name", "file", "pid", "path", "module", "version", "latest", "go",
+	"deps", "revision", "modified", "size", "mtime", "archive", "image",
+}
+
+// TableGroups are the values of Table.GroupBy.
+var TableGroups = []string{"module", "go"}
+
+var columns = map[string]column{
+	"name": {name: "NAME", value: func(t Target) string {
+		switch t := t.(type) {
+		case *Process:
+			return t.Name
+		case *GoMod:
+			return filepath.Base(filepath.Dir(t.File))
+		}
+		return filepath.Base(tableBinary(t).File)
+	}},
+	"file": {name: "FILE", value: func(t Target) string {
+		if m, ok := t.(*GoMod); ok {
+			return m.File
+		}
+		return tableBinary(t).File
+	}},
+	"pid": {name: "PID", value: func(t Target) string {
+		if p, ok := t.(*Process); ok {
+			return strconv.Itoa(int(p.PID))
+		}
+		return ""
+	}, compare: func(a, b Target) int {
+		return cmp.Compare(tablePID(a), tablePID(b))
+	}},
+	"path": {name: "PATH", value: func(t Target) string {
+		if m, ok := t.(*GoMod); ok {
+			return m.Path
+		}
+		return tableBinary(t).Path
+	}},
+	"module":  {name: "MODULE", value: tableModule},
+	"version": {name: "VERSION", value: tableVersion, compare: semverColumn(tableVersion)},
+	"latest":  {name: "LATEST", value: tableLatest, compare: semverColumn(tableLatest)},
+	"go":      {name: "GO", value: tableGoVersion, compare: semverColumn(tableGoVersion)},
+	"deps": {name: "DEPS", value: func(t Target) string {
+		return strconv.Itoa(tableDeps(t))
+	}, compare: func(a, b Target) int {
+		return cmp.Compare(tableDeps(a), tableDeps(b))
+	}},
+	"revision": {name: "REVISION", value: func(t Target) string {
+		return setting(t, "vcs.revision")
+	}},
+	"modified": {name: "MODIFIED", value: func(t Target) string {
+		return setting(t, "vcs.modified")
+	}},
+	"size": {name: "SIZE", value: func(t Target) string {
+		if b := tableBinary(t); b != nil && b.Size != 0 {
+			return formatSize(b.Size)
+		}
+		return ""
+	}, compare: func(a, b Target) int {
+		return cmp.Compare(tableSize(a), tableSize(b))
+	}},
+	"archive": {name: "ARCHIVE", value: func(t Target) string {
+		if b := tableBinary(t); b != nil {
+			return b.Archive
+		}
+		return ""
+	}},
+	"image": {name: "IMAGE", value: func(t Target) string {
+		if b := tableBinary(t); b != nil {
+			return b.Image
+		}
+		return ""
+	}},
+	"mtime": {name: "MTIME", value: func(t Target) string {
+		if b := tableBinary(t); b != nil && b.ModTime != nil {
+			return b.ModTime.Format("2006-01-02 15:04")
+		}
+		return ""
+	}, compare: func(a, b Target) int {
+		return tableModTime(a).Compare(tableModTime(b))
+	}},
+}
+
+// defaultColumns are the columns shown by the commands unless selected explicitly.
+func defaultColumns(command string) []string {
+	switch command {
+	case "process":
+		return []string{"pid", "name", "path", "version", "go"}
+	case "module find":
+		return []string{"file", "path", "go", "deps"}
+	}
+	return []string{"name", "path", "version", "go", "deps"}
+}
+
+func (t *Table) validate() error {
+	for _, c := range t.Columns {
+		if _, ok := columns[c]; !ok {
+			return fmt.Errorf("%w: %q", errUnknownColumn, c)
+		}
+	}
+	if s := strings.TrimPrefix(t.Sort, "-"); s != "" {
+		if _, ok := columns[s]; !ok {
+			return fmt.Errorf("%w: %q", errUnknownColumn, s)
+		}
+	}
+	if t.GroupBy != "" && !slices.Contains(TableGroups, t.GroupBy) {
+		return fmt.Errorf("%w: %q", errUnknownGroup, t.GroupBy)
+	}
+	return nil
+}
+
+// tableWriter buffers the targets and prints them as an aligned table on Close.
+type tableWriter struct {
+	w       io.Writer
+	opts    Options
+	targets []Target
+}
+
+func (t *tableWriter) Write(target Target) error {
+	t.targets = append(t.targets, target)
+	return nil
+}
+
+func (t *tableWriter) Close() error {
+	table := t.opts.Table
+	names := table.Columns
+	if len(names) == 0 {
+		names = defaultColumns(t.opts.Command)
+	}
+
+	if table.Sort != "" {
+		c := columns[strings.TrimPrefix(table.Sort, "-")]
+		compare := c.compare
+		if compare == nil {
+			compare = func(a, b Target) int { return strings.Compare(c.value(a), c.value(b)) }
+		}
+		slices.SortStableFunc(t.targets, func(a, b Target) int {
+			if strings.HasPrefix(table.Sort, "-") {
+				return compare(b, a)
+			}
+			return compare(a, b)
+		})
+	}
+
+	groups := [][]Target{t.targets}
+	var titles []string
+	if table.GroupBy != "" {
+		groups, titles = t.group(table.GroupBy)
+	}
+
+	for i, rows := range groups {
+		if titles != nil {
+			if i != 0 {
+				fmt.Fprintln(t.w)
+			}
+			fmt.Fprintf(t.w, "%s (%d)\n", titles[i], len(rows))
+		}
+		tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+		headers := make([]string, len(names))
+		for j, n := range names {
+			headers[j] = columns[n].name
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+		for _, row := range rows {
+			values := make([]string, len(names))
+			for j, n := range names {
+				values[j] = columns[n].value(row)
+				if values[j] == "" {
+					values[j] = "-"
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(values, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// group splits the targets into groups in the order of their first appearance.
+func (t *tableWriter) group(by string) ([][]Target, []string) {
+	key := tableModule
+	if by == "go" {
+		key = tableGoVersion
+	}
+	var groups [][]Target
+	var titles []string
+	for _, target := range t.targets {
+		k := key(target)
+		if k == "" {
+			k = "-"
+		}
+		i := slices.Index(titles, k)
+		if i < 0 {
+			i = len(titles)
+			titles = append(titles, k)
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], target)
+	}
+	return groups, titles
+}
+
+func tableBinary(t Target) *Binary {
+	switch t := t.(type) {
+	case *Binary:
+		return t
+	case *Process:
+		return t.Binary
+	}
+	return nil
+}
+
+func tableModule(t Target) string {
+	if m, ok := t.(*GoMod); ok {
+		return m.Path
+	}
+	return tableBinary(t).Main.Path
+}
+
+func tableGoVersion(t Target) string {
+	if m, ok := t.(*GoMod); ok && m.GoVersion != "" {
+		return "go" + m.GoVersion
+	}
+	if b := tableBinary(t); b != nil {
+		return b.GoVersion
+	}
+	return ""
+}
+
+func tableDeps(t Target) int {
+	if m, ok := t.(*GoMod); ok {
+		return len(m.Requires)
+	}
+	return len(tableBinary(t).Deps)
+}
+
+func tablePID(t Target) int32 {
+	if p, ok := t.(*Process); ok {
+		return p.PID
+	}
+	return 0
+}
+
+func tableSize(t Target) int64 {
+	if b := tableBinary(t); b != nil {
+		return b.Size
+	}
+	return 0
+}
+
+// tableModTime returns the modification time of the binary, or the zero time which
+// sorts first if it is unknown.
+func tableModTime(t Target) time.Time {
+	if b := tableBinary(t); b != nil && b.ModTime != nil {
+		return *b.ModTime
+	}
+	return time.Time{}
+}
+
+func tableVersion(t Target) string {
+	if b := tableBinary(t); b != nil {
+		return b.Main.Version
+	}
+	return ""
+}
+
+func tableLatest(t Target) string {
+	if b := tableBinary(t); b != nil && b.Main.Latest != nil {
+		return latestVersion(b.Main)
+	}
+	return ""
+}
+
+// semverColumn compares the values of a column as versions.
+func semverColumn(value func(Target) string) func(a, b Target) int {
+	return func(a, b Target) int {
+		return semverCompare(value(a), value(b))
+	}
+}
+
+// formatSize formats the number of bytes with a binary unit, e.g. "12.3M".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,124 @@
+package xmreport
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+// Layouts of the text output format, executed with the same functions as the user templates.
+var (
+	binaryHeaderTemplate  = mustParse("{{.Path}} [{{.GoVersion}} | {{len .Deps}} deps | mod: {{.Main.Path}}]")
+	processHeaderTemplate = mustParse("{{.Name}} [{{.PID}}]")
+)
+
+// TemplateFuncs are the functions available to the templates in addition to the built-in ones.
+var TemplateFuncs = template.FuncMap{
+	// semverCompare returns -1, 0 or +1 like semver.Compare.
+	"semverCompare": semverCompare,
+	// semverLess reports whether the version v is lower than w.
+	"semverLess":  func(v, w string) bool { return semverCompare(v, w) < 0 },
+	"semverMajor": func(v string) string { return semver.Major(toSemver(v)) },
+	// joinDeps joins the "path@version" of the modules or the requirements with sep.
+	"joinDeps": joinDeps,
+	// setting returns the value of the build setting of the binary or the process.
+	"setting": setting,
+	"join":    strings.Join,
+}
+
+func mustParse(text string) *template.Template {
+	return template.Must(template.New("").Funcs(TemplateFuncs).Parse(text))
+}
+
+// semverCompare compares the versions, Go versions like "go1.22.1" are accepted too.
+func semverCompare(v, w string) int {
+	return semver.Compare(toSemver(v), toSemver(w))
+}
+
+func toSemver(v string) string {
+	if strings.HasPrefix(v, "go") {
+		return xmvuln.GoVersion(v)
+	}
+	return v
+}
+
+func joinDeps(deps any, sep string) string {
+	var mods []*Module
+	switch deps := deps.(type) {
+	case []*Module:
+		mods = deps
+	case []*Require:
+		for _, r := range deps {
+			mods = append(mods, r.Module)
+		}
+	}
+	s := make([]string, 0, len(mods))
+	for _, m := range mods {
+		if m.Version == "" {
+			s = append(s, m.Path)
+		} else {
+			s = append(s, m.Path+"@"+m.Version)
+		}
+	}
+	return strings.Join(s, sep)
+}
+
+func setting(t Target, key string) string {
+	var b *Binary
+	switch t := t.(type) {
+	case *Binary:
+		b = t
+	case *Process:
+		b = t.Binary
+	}
+	if b == nil {
+		return ""
+	}
+	v, _ := b.Setting(key)
+	return v
+}
+
+// templateWriter executes the template for every target. Empty outputs are skipped,
+// the others end with a new line.
+type templateWriter struct {
+	w    io.Writer
+	tmpl *template.Template
+	buf  bytes.Buffer
+}
+
+// NewTemplateWriter returns a writer executing the text/template with every target.
+func NewTemplateWriter(w io.Writer, text string, opts Options) (Writer, error) {
+	tmpl, err := template.New("format").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var tw Writer = &templateWriter{w: w, tmpl: tmpl}
+	if opts.Filter != nil {
+		tw = &filterWriter{Writer: tw, keep: opts.Filter}
+	}
+	return tw, nil
+}
+
+func (t *templateWriter) Write(target Target) error {
+	t.buf.Reset()
+	if err := t.tmpl.Execute(&t.buf, target); err != nil {
+		return err
+	}
+	if t.buf.Len() == 0 {
+		return nil
+	}
+	if !bytes.HasSuffix(t.buf.Bytes(), []byte("\n")) {
+		t.buf.WriteByte('\n')
+	}
+	_, err := t.w.Write(t.buf.Bytes())
+	return err
+}
+
+func (t *templateWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,671 @@
+package xmreport
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"github.com/o7q2ab/goxm/internal/xmsym"
+)
+
+const separator = "---------------"
+
+type textWriter struct {
+	w    io.Writer
+	opts Options
+	idx  int
+
+	// mods are buffered by "module find", which prints their number first.
+	mods []*GoMod
+}
+
+func (t *textWriter) Write(target Target) error {
+	switch target := target.(type) {
+	case *Binary:
+		t.next()
+		t.binary(target)
+	case *Process:
+		t.next()
+		t.process(target)
+	case *GoMod:
+		if t.opts.Command == "module find" {
+			t.mods = append(t.mods, target)
+			return nil
+		}
+		t.next()
+		t.module(target)
+	case *Diff:
+		t.next()
+		t.diff(target)
+	case *Drift:
+		t.next()
+		t.drift(target)
+	case *Size:
+		t.next()
+		t.size(target)
+	}
+	return nil
+}
+
+func (t *textWriter) Close() error {
+	switch t.opts.Command {
+	case "binary", "path", "image", "vuln", "check":
+		if t.idx == 0 {
+			fmt.Fprintln(t.w, "No Go binary files were found.")
+		}
+	case "module find":
+		fmt.Fprintf(t.w, "Found %d go.mod files.\n\n", len(t.mods))
+		for _, m := range t.mods {
+			t.next()
+			t.moduleSummary(m)
+		}
+	}
+	return nil
+}
+
+func (t *textWriter) next() {
+	if t.idx != 0 {
+		fmt.Fprintln(t.w, separator)
+	}
+	t.idx++
+}
+
+func (t *textWriter) binary(b *Binary) {
+	if !t.opts.Short {
+		switch {
+		case b.Image != "":
+			fmt.Fprintf(t.w, "%d | %s %s [layer %s]\n", t.idx, b.Image, b.File, shortDigest(b.Layer))
+		case b.Archive != "":
+			fmt.Fprintf(t.w, "%d | %s %s\n", t.idx, b.Archive, b.File)
+		default:
+			fmt.Fprintf(t.w, "%d | %s\n", t.idx, b.File)
+		}
+	}
+	t.binaryHeader(b)
+
+	if t.opts.ShowDeps || t.opts.ShowBuild {
+		fmt.Fprintf(t.w, "\ncurrent: %s\n", b.Main.Version)
+		if c := b.Main.Commit; c != nil {
+			fmt.Fprintf(t.w, "commit: %s\n", commitDetails(c))
+		}
+		fmt.Fprintf(t.w, "%s: %s\n", latestLabel(b.Main), latestVersion(b.Main))
+		if b.Main.NewerRelease != "" {
+			fmt.Fprintf(t.w, "newer release: %s (published after the commit)\n", b.Main.NewerRelease)
+		}
+		if b.Main.Major != nil {
+			fmt.Fprintf(t.w, "major upgrade available: %s\n", majorVersion(b.Main))
+		}
+		if s := strings.TrimSpace(noticeSuffix(b.Main)); s != "" {
+			fmt.Fprintln(t.w, s)
+		}
+	}
+	if t.opts.ShowDeps {
+		fmt.Fprintf(t.w, "\nDependencies:\n")
+		for _, d := range b.Deps {
+			suffix := ""
+			if t.opts.ShowLatest {
+				suffix = commitSuffix(d) + latestSuffix(d) + majorSuffix(d) + updateSuffix(d) + noticeSuffix(d)
+			}
+			fmt.Fprintf(t.w, "    %s %s%s\n", d.Path, d.Version, suffix)
+		}
+		if t.opts.ShowLatest {
+			t.summary(b.Summary)
+		}
+	}
+	if t.opts.ShowBuild {
+		t.settings(b)
+	}
+	if t.opts.ShowHardening {
+		t.hardening(b)
+	}
+	if t.opts.ShowPkgs {
+		t.packages(b)
+	}
+	if t.opts.ShowVulns {
+		t.vulns(append([]*Module{b.Stdlib, b.Main}, b.Deps...))
+	}
+	if t.opts.ShowViolations {
+		t.violations(b.Violations)
+	}
+}
+
+func (t *textWriter) violations(vs []*Violation) {
+	fmt.Fprintf(t.w, "\nViolations:\n")
+	for _, v := range vs {
+		fmt.Fprintf(t.w, "    [%s] %s\n", v.Rule, v.Message)
+	}
+	if len(vs) == 0 {
+		fmt.Fprintln(t.w, "    no violations")
+	}
+}
+
+func (t *textWriter) vulns(mods []*Module) {
+	fmt.Fprintf(t.w, "\nVulnerabilities:\n")
+	n := 0
+	for _, m := range mods {
+		if m == nil {
+			continue
+		}
+		for _, v := range m.Vulns {
+			n++
+			id := v.ID
+			if len(v.Aliases) != 0 {
+				id += " (" + strings.Join(v.Aliases, ", ") + ")"
+			}
+			fixed := "no fix available"
+			if v.Fixed != "" {
+				fixed = "fixed in " + v.Fixed
+			}
+			fmt.Fprintf(t.w, "    %s %s: %s, %s: %s\n", m.Path, m.Version, id, fixed, v.Summary)
+		}
+	}
+	if n == 0 {
+		fmt.Fprintln(t.w, "    no known vulnerabilities")
+	}
+}
+
+func (t *textWriter) summary(s *Summary) {
+	if s == nil {
+		return
+	}
+	updates := []string{}
+	for _, kind := range []string{"patch", "minor", "major", "pseudo"} {
+		if n := s.Updates[kind]; n != 0 {
+			updates = append(updates, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	details := ""
+	if len(updates) != 0 {
+		details = " (" + strings.Join(updates, ", ") + ")"
+	}
+	fmt.Fprintf(
+		t.w,
+		"\nSummary: %d of %d modules outdated%s | libyear: %.2f\n",
+		s.Outdated, s.Modules, details, s.Libyear,
+	)
+}
+
+func (t *textWriter) binaryHeader(b *Binary) {
+	t.line(binaryHeaderTemplate, b)
+}
+
+// line executes the layout template and ends the output with a new line.
+func (t *textWriter) line(tmpl *template.Template, data any) {
+	if err := tmpl.Execute(t.w, data); err != nil {
+		fmt.Fprint(t.w, "error: ", err)
+	}
+	fmt.Fprintln(t.w)
+}
+
+func (t *textWriter) settings(b *Binary) {
+	fmt.Fprintf(t.w, "\nBuild settings:\n")
+	for _, s := range b.Settings {
+		fmt.Fprintf(t.w, "    %s=%s\n", s.Key, s.Value)
+	}
+}
+
+func (t *textWriter) hardening(b *Binary) {
+	fmt.Fprintf(t.w, "\nHardening:\n")
+	h := b.Hardening
+	if h == nil {
+		fmt.Fprintf(t.w, "    error: %s\n", b.HardeningError)
+		return
+	}
+	fmt.Fprintf(t.w, "    format: %s\n", h.Format)
+	fmt.Fprintf(t.w, "    buildmode: %s\n", h.BuildMode)
+	fmt.Fprintf(t.w, "    trimpath: %s\n", yesNo(h.Trimpath))
+	if len(h.Arches) == 0 {
+		t.hardeningProperties(h, "    ")
+		return
+	}
+	// The universal binaries are printed by their architectures.
+	for _, a := range h.Arches {
+		fmt.Fprintf(t.w, "    %s:\n", a.Arch)
+		t.hardeningProperties(a, "        ")
+	}
+}
+
+// hardeningProperties prints the properties read from the headers of the executable.
+func (t *textWriter) hardeningProperties(h *Hardening, indent string) {
+	relro := "no"
+	switch {
+	case h.RELRO && h.BindNow:
+		relro = "full"
+	case h.RELRO:
+		relro = "partial"
+	}
+	interpreter := h.Interpreter
+	if interpreter == "" {
+		interpreter = "-"
+	}
+	needed := strings.Join(h.Needed, ", ")
+	if needed == "" {
+		needed = "-"
+	}
+	fmt.Fprintf(t.w, "%spie: %s\n", indent, yesNo(h.PIE))
+	if h.Format == "elf" {
+		fmt.Fprintf(t.w, "%srelro: %s\n", indent, relro)
+	}
+	fmt.Fprintf(t.w, "%snx stack: %s\n", indent, yesNo(h.NXStack))
+	fmt.Fprintf(t.w, "%sstatic: %s\n", indent, yesNo(h.Static))
+	fmt.Fprintf(t.w, "%ssymtab: %s\n", indent, yesNo(h.Symtab))
+	fmt.Fprintf(t.w, "%sdwarf: %s\n", indent, yesNo(h.DWARF))
+	fmt.Fprintf(t.w, "%sinterpreter: %s\n", indent, interpreter)
+	fmt.Fprintf(t.w, "%sneeded: %s\n", indent, needed)
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+// packages prints the linked packages under the headings of their modules.
+func (t *textWriter) packages(b *Binary) {
+	fmt.Fprintf(t.w, "\nPackages:\n")
+	if b.PackagesError != "" {
+		fmt.Fprintf(t.w, "    error: %s\n", b.PackagesError)
+		return
+	}
+	versions := map[string]string{b.Main.Path: b.Main.Version}
+	for _, d := range b.Deps {
+		versions[d.Path] = d.Version
+	}
+	for i, p := range b.Packages {
+		if i == 0 || p.Module != b.Packages[i-1].Module {
+			switch {
+			case p.Module == "":
+				fmt.Fprintln(t.w, "    (unknown module)")
+			case versions[p.Module] != "":
+				fmt.Fprintf(t.w, "    %s %s\n", p.Module, versions[p.Module])
+			default:
+				fmt.Fprintf(t.w, "    %s\n", p.Module)
+			}
+		}
+		fmt.Fprintf(t.w, "        %s\n", p.Path)
+		if t.opts.ShowFiles {
+			for _, f := range p.Files {
+				fmt.Fprintf(t.w, "            %s\n", f)
+			}
+		}
+	}
+}
+
+func (t *textWriter) process(p *Process) {
+	fmt.Fprintf(t.w, "%d | ", t.idx)
+	t.line(processHeaderTemplate, p)
+	t.binaryHeader(p.Binary)
+
+	if t.opts.ShowDeps {
+		fmt.Fprintf(t.w, "\nDependencies:\n")
+		for _, d := range p.Deps {
+			fmt.Fprintf(t.w, "    %s %s\n", d.Path, d.Version)
+		}
+	}
+	if t.opts.ShowBuild {
+		t.settings(p.Binary)
+	}
+	if t.opts.ShowVulns {
+		t.vulns(append([]*Module{p.Stdlib, p.Main}, p.Deps...))
+	}
+	if t.opts.ShowViolations {
+		t.violations(p.Violations)
+	}
+	if t.opts.ShowConn {
+		fmt.Fprintf(t.w, "\nConnections:\n")
+		if p.ConnError != "" {
+			fmt.Fprintln(t.w, "    error:", p.ConnError)
+			return
+		}
+		if len(p.Connections) == 0 {
+			fmt.Fprintln(t.w, "    no connections")
+		}
+		for _, c := range p.Connections {
+			fmt.Fprintf(
+				t.w,
+				"    %s %s:%d - %s:%d\n",
+				c.Family,
+				c.Local.IP, c.Local.Port,
+				c.Remote.IP, c.Remote.Port,
+			)
+		}
+	}
+}
+
+func (t *textWriter) module(m *GoMod) {
+	if m.Error != "" {
+		fmt.Fprintln(t.w, "error:", m.Error)
+		return
+	}
+	fmt.Fprintln(t.w, "Module root dir:", filepath.Dir(m.File))
+	fmt.Fprintln(t.w, m.Path)
+	if t.opts.ShowVulns {
+		defer t.vulns(requiredModules(m))
+	}
+	if t.opts.ShowViolations {
+		defer t.violations(m.Violations)
+	}
+	if t.opts.Command == "vuln" || t.opts.Command == "check" {
+		return
+	}
+	for _, r := range m.Requires {
+		suffix := commitSuffix(r.Module)
+		if r.Latest != nil && r.Latest.Version != r.Version {
+			suffix += latestSuffix(r.Module)
+		}
+		suffix += majorSuffix(r.Module) + updateSuffix(r.Module) + noticeSuffix(r.Module)
+		if r.Indirect {
+			fmt.Fprintf(t.w, "    [indirect] %s %s%s\n", r.Path, r.Version, suffix)
+		} else {
+			fmt.Fprintf(t.w, "    %s %s%s\n", r.Path, r.Version, suffix)
+		}
+	}
+	t.summary(m.Summary)
+}
+
+func (t *textWriter) diff(d *Diff) {
+	fmt.Fprintln(t.w, "---", d.Old)
+	fmt.Fprintln(t.w, "+++", d.New)
+	if d.Empty() {
+		fmt.Fprintln(t.w, "No differences.")
+		return
+	}
+	t.diffBody(d, "")
+}
+
+// diffBody prints the changes of the diff, with every line indented by indent.
+// The sections are separated by empty lines unless indented.
+func (t *textWriter) diffBody(d *Diff, indent string) {
+	section := func(title string) {
+		if indent == "" {
+			fmt.Fprintln(t.w)
+		}
+		fmt.Fprintln(t.w, indent+title)
+	}
+	if d.GoVersion != nil {
+		fmt.Fprintln(t.w, indent+"Go version:", changeString(d.GoVersion))
+	}
+	if d.Path != nil {
+		fmt.Fprintln(t.w, indent+"Path:", changeString(d.Path))
+	}
+	if d.Main != nil {
+		fmt.Fprintln(t.w, indent+"Main module:", changeString(d.Main))
+	}
+	if len(d.Deps) != 0 {
+		section("Dependencies:")
+		for _, c := range d.Deps {
+			line := c.Path
+			switch {
+			case c.Kind == DepAdded:
+				line += " " + c.Version.New
+			case c.Kind == DepRemoved:
+				line += " " + c.Version.Old
+			case c.Version != nil:
+				line += " " + changeString(c.Version)
+			}
+			if c.Replace != nil {
+				line += " (replace: " + changeString(c.Replace) + ")"
+			}
+			fmt.Fprintf(t.w, "%s    %-10s %s\n", indent, c.Kind, line)
+		}
+	}
+	if len(d.Settings) != 0 {
+		section("Build settings:")
+		for _, s := range d.Settings {
+			fmt.Fprintf(t.w, "%s    %s: %s\n", indent, s.Key, changeString(&s.Change))
+		}
+	}
+}
+
+func (t *textWriter) drift(d *Drift) {
+	fmt.Fprintln(t.w, "---", d.Old)
+	fmt.Fprintln(t.w, "+++", d.New)
+	if d.Empty() {
+		fmt.Fprintln(t.w, "No differences.")
+		return
+	}
+	items := func(title string, items []*Item) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(t.w, "\n%s:\n", title)
+		for _, it := range items {
+			line := it.File
+			if it.PID != 0 {
+				line = fmt.Sprintf("%d %s %s", it.PID, it.Name, it.File)
+			}
+			if it.Path != "" {
+				line += " " + strings.TrimSpace(it.Path+" "+it.Version)
+			}
+			if it.GoVersion != "" {
+				line += " [" + it.GoVersion + "]"
+			}
+			fmt.Fprintln(t.w, "    "+line)
+		}
+	}
+	diffs := func(title string, diffs []*Diff) {
+		if len(diffs) == 0 {
+			return
+		}
+		fmt.Fprintf(t.w, "\n%s:\n", title)
+		for _, d := range diffs {
+			fmt.Fprintln(t.w, "    "+d.New)
+			t.diffBody(d, "        ")
+		}
+	}
+	items("New binaries", d.AddedBinaries)
+	items("Removed binaries", d.RemovedBinaries)
+	diffs("Changed binaries", d.ChangedBinaries)
+	items("New processes", d.AddedProcesses)
+	items("Removed processes", d.RemovedProcesses)
+	diffs("Changed processes", d.ChangedProcesses)
+	items("New modules", d.AddedModules)
+	items("Removed modules", d.RemovedModules)
+	diffs("Changed modules", d.ChangedModules)
+}
+
+// size prints how the sections of the binary are shared, and its largest modules and packages.
+func (t *textWriter) size(s *Size) {
+	if !t.opts.Short {
+		fmt.Fprintf(t.w, "%d | %s\n", t.idx, s.File)
+	}
+	total := s.SectionsSize()
+	var kinds []string
+	for _, k := range xmsym.Kinds {
+		kinds = append(kinds, k+" "+formatSize(s.Sections[k]))
+	}
+	fmt.Fprintf(t.w, "sections: %s of %s file (%s)\n", formatSize(total), formatSize(s.Total), strings.Join(kinds, ", "))
+	if s.Stripped {
+		fmt.Fprintln(t.w, "no symbol table: only the text and the pclntab of the functions are attributed")
+	}
+
+	fmt.Fprintf(t.w, "\nShare:\n")
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	for _, share := range []struct {
+		label string
+		n     int64
+	}{
+		{"standard library", s.Shares.Std},
+		{"main module", s.Shares.Main},
+		{"dependencies", s.Shares.Deps},
+		{"other packages", s.Shares.Other},
+		{"unattributed", s.Shares.Unattributed},
+	} {
+		if share.n != 0 || share.label != "other packages" {
+			fmt.Fprintf(tw, "    %s\t%s\t%s\n", share.label, formatSize(share.n), percent(share.n, total))
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintf(t.w, "\nModules:\n")
+	t.sizeEntries(s.Modules, total, func(e *SizeEntry) string {
+		switch {
+		case e.Name == "":
+			return "(unknown module)"
+		case e.Version != "":
+			return e.Name + " " + e.Version
+		}
+		return e.Name
+	})
+	fmt.Fprintf(t.w, "\nPackages:\n")
+	t.sizeEntries(s.Packages, total, func(e *SizeEntry) string { return e.Name })
+}
+
+// sizeEntries prints the largest entries as a table, up to the Top option.
+func (t *textWriter) sizeEntries(entries []*SizeEntry, total int64, name func(*SizeEntry) string) {
+	n := len(entries)
+	if t.opts.Top > 0 {
+		n = min(n, t.opts.Top)
+	}
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "    NAME\tSIZE\tSHARE\t%s\n", strings.ToUpper(strings.Join(xmsym.Kinds, "\t")))
+	for _, e := range entries[:n] {
+		fmt.Fprintf(tw, "    %s\t%s\t%s", name(e), formatSize(e.Size), percent(e.Size, total))
+		for _, k := range xmsym.Kinds {
+			fmt.Fprintf(tw, "\t%s", formatSize(e.Sections[k]))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	if n < len(entries) {
+		fmt.Fprintf(t.w, "    ... %d more\n", len(entries)-n)
+	}
+}
+
+func percent(n, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// changeString formats the change as "old -> new".
+func changeString(c *Change) string {
+	old, new := c.Old, c.New
+	if old == "" {
+		old = "(none)"
+	}
+	if new == "" {
+		new = "(none)"
+	}
+	return old + " -> " + new
+}
+
+func requiredModules(m *GoMod) []*Module {
+	mods := make([]*Module, 0, len(m.Requires))
+	for _, r := range m.Requires {
+		mods = append(mods, r.Module)
+	}
+	return mods
+}
+
+func (t *textWriter) moduleSummary(m *GoMod) {
+	if m.Error != "" {
+		fmt.Fprintln(t.w, "error:", m.Error)
+		return
+	}
+	d, err := filepath.Rel(t.opts.Root, filepath.Dir(m.File))
+	if err != nil {
+		d = filepath.Dir(m.File)
+	}
+	fmt.Fprintln(t.w, "- Module root dir:", d)
+	fmt.Fprintf(t.w, "  %s [go %s | %d deps]\n", m.Path, m.GoVersion, len(m.Requires))
+}
+
+func latestVersion(m *Module) string {
+	switch {
+	case m.Latest == nil:
+		return ""
+	case m.Latest.Error != "":
+		return "error: " + m.Latest.Error
+	case m.Latest.Version == "":
+		return "unknown"
+	}
+	return m.Latest.Version
+}
+
+func latestLabel(m *Module) string {
+	if m.Latest != nil && m.Latest.Offline {
+		return "latest cached"
+	}
+	return "latest"
+}
+
+func latestSuffix(m *Module) string {
+	return fmt.Sprintf(" (%s: %s)", latestLabel(m), latestVersion(m))
+}
+
+// majorVersion describes the major upgrade by the suffix of its module path, e.g. "/v3 v3.2.1".
+func majorVersion(m *Module) string {
+	prefix, _, _ := module.SplitPathVersion(m.Path)
+	return strings.TrimPrefix(m.Major.Path, prefix) + " " + m.Major.Version
+}
+
+func commitDetails(c *Commit) string {
+	s := c.Revision
+	if !c.Time.IsZero() {
+		s += " from " + c.Time.Format(time.DateOnly)
+	}
+	if c.Base != "" {
+		s += ", based on " + c.Base
+	}
+	if c.Modified {
+		s += ", modified"
+	}
+	return s
+}
+
+func commitSuffix(m *Module) string {
+	if m.Commit == nil {
+		return ""
+	}
+	return fmt.Sprintf(" [commit %s]", commitDetails(m.Commit))
+}
+
+func updateSuffix(m *Module) string {
+	switch {
+	case m.Update == "":
+		return ""
+	case m.DaysBehind > 0:
+		return fmt.Sprintf(" [%s, %d days behind]", m.Update, m.DaysBehind)
+	}
+	return fmt.Sprintf(" [%s]", m.Update)
+}
+
+func noticeSuffix(m *Module) string {
+	s := ""
+	if m.Retracted != nil {
+		if m.Retracted.Rationale != "" {
+			s += fmt.Sprintf(" [RETRACTED: %s]", m.Retracted.Rationale)
+		} else {
+			s += " [RETRACTED]"
+		}
+	}
+	if m.Deprecated != "" {
+		s += fmt.Sprintf(" [DEPRECATED: %s]", m.Deprecated)
+	}
+	return s
+}
+
+func majorSuffix(m *Module) string {
+	if m.Major == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (major upgrade available: %s)", majorVersion(m))
+}
+
+// shortDigest abbreviates the digest like docker does, e.g. "sha256:0123456789ab".
+func shortDigest(d string) string {
+	alg, hex, ok := strings.Cut(d, ":")
+	if !ok || len(hex) <= 12 {
+		return d
+	}
+	return alg + ":" + hex[:12]
+}
@@ -0,0 +1,220 @@
+package xmreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatSARIF = "sarif"
+)
+
+var (
+	Formats = []string{FormatText, FormatJSON, FormatJSONL, FormatSARIF}
+
+	errUnknownFormat = errors.New("unknown output format")
+	errUnknownSchema = errors.New("unknown schema")
+	errNoFindings    = errors.New("no findings to report in SARIF")
+)
+
+// Writer renders targets in one of the supported output formats.
+// Close must be called once all the targets have been written.
+type Writer interface {
+	Write(Target) error
+	Close() error
+}
+
+// Options describe what the user asked to see. The structured formats
+// always contain everything that was gathered and ignore the Show* fields.
+type Options struct {
+	// Command is the name of the command producing the output, e.g. "path" or "module find".
+	Command string
+	// Root is the directory the command was started from, used to shorten file names.
+	Root string
+	// Short omits the index and the file name of a single examined binary.
+	Short bool
+
+	ShowDeps   bool
+	ShowLatest bool
+	ShowBuild  bool
+	ShowConn   bool
+	ShowVulns  bool
+	ShowPkgs   bool
+	ShowFiles  bool
+	// ShowHardening prints the security properties of the executables.
+	ShowHardening bool
+	// ShowViolations prints the violations of the policy the targets are checked against.
+	ShowViolations bool
+
+	// Top limits the number of the modules and the packages in the text output
+	// of the sizes, 0 means no limit.
+	Top int
+
+	// Table, if set, prints the targets as a table in the text format.
+	Table *Table
+
+	// Filter, if set, selects the dependency modules to output. It applies to all the formats.
+	Filter func(*Module) bool
+}
+
+func NewWriter(w io.Writer, format string, opts Options) (Writer, error) {
+	var tw Writer
+	switch format {
+	case FormatText:
+		tw = &textWriter{w: w, opts: opts}
+		if opts.Table != nil {
+			if err := opts.Table.validate(); err != nil {
+				return nil, err
+			}
+			tw = &tableWriter{w: w, opts: opts}
+		}
+	case FormatJSON:
+		tw = &jsonWriter{w: w, report: Report{Schema: Schema, Command: opts.Command}}
+	case FormatJSONL:
+		tw = &jsonlWriter{enc: json.NewEncoder(w)}
+	case FormatSARIF:
+		tw = &sarifWriter{w: w, rules: map[string]*sarifRule{}}
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownFormat, format)
+	}
+	if opts.Filter != nil {
+		tw = &filterWriter{Writer: tw, keep: opts.Filter}
+	}
+	return tw, nil
+}
+
+// Report is the document written by the "json" output format.
+type Report struct {
+	Schema  string `json:"schema"`
+	Command string `json:"command"`
+	// Created and Host are set for snapshots.
+	Created   *time.Time `json:"created,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	Binaries  []*Binary  `json:"binaries,omitempty"`
+	Processes []*Process `json:"processes,omitempty"`
+	Modules   []*GoMod   `json:"modules,omitempty"`
+	Diffs     []*Diff    `json:"diffs,omitempty"`
+	Drifts    []*Drift   `json:"drifts,omitempty"`
+	Sizes     []*Size    `json:"sizes,omitempty"`
+}
+
+func (r *Report) add(t Target) {
+	switch t := t.(type) {
+	case *Binary:
+		r.Binaries = append(r.Binaries, t)
+	case *Process:
+		r.Processes = append(r.Processes, t)
+	case *GoMod:
+		r.Modules = append(r.Modules, t)
+	case *Diff:
+		r.Diffs = append(r.Diffs, t)
+	case *Drift:
+		r.Drifts = append(r.Drifts, t)
+	case *Size:
+		r.Sizes = append(r.Sizes, t)
+	}
+}
+
+// Record is a single line written by the "jsonl" output format.
+type Record struct {
+	Schema  string   `json:"schema"`
+	Kind    string   `json:"kind"`
+	Binary  *Binary  `json:"binary,omitempty"`
+	Process *Process `json:"process,omitempty"`
+	Module  *GoMod   `json:"module,omitempty"`
+	Diff    *Diff    `json:"diff,omitempty"`
+	Drift   *Drift   `json:"drift,omitempty"`
+	Size    *Size    `json:"size,omitempty"`
+}
+
+func NewRecord(t Target) Record {
+	r := Record{Schema: Schema, Kind: t.kind()}
+	switch t := t.(type) {
+	case *Binary:
+		r.Binary = t
+	case *Process:
+		r.Process = t
+	case *GoMod:
+		r.Module = t
+	case *Diff:
+		r.Diff = t
+	case *Drift:
+		r.Drift = t
+	case *Size:
+		r.Size = t
+	}
+	return r
+}
+
+type jsonWriter struct {
+	w      io.Writer
+	report Report
+}
+
+func (j *jsonWriter) Write(t Target) error {
+	j.report.add(t)
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.report)
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(t Target) error {
+	return j.enc.Encode(NewRecord(t))
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// filterWriter passes copies of the targets with the filtered dependency modules to the Writer.
+type filterWriter struct {
+	Writer
+	keep func(*Module) bool
+}
+
+func (f *filterWriter) Write(t Target) error {
+	switch t := t.(type) {
+	case *Binary:
+		return f.Writer.Write(f.binary(t))
+	case *Process:
+		p := *t
+		p.Binary = f.binary(t.Binary)
+		return f.Writer.Write(&p)
+	case *GoMod:
+		m := *t
+		m.Requires = []*Require{}
+		for _, r := range t.Requires {
+			if f.keep(r.Module) {
+				m.Requires = append(m.Requires, r)
+			}
+		}
+		return f.Writer.Write(&m)
+	}
+	return f.Writer.Write(t)
+}
+
+func (f *filterWriter) binary(t *Binary) *Binary {
+	b := *t
+	// The filtered deps are never null in the JSON output, even if none is kept.
+	b.Deps = []*Module{}
+	for _, d := range t.Deps {
+		if f.keep(d) {
+			b.Deps = append(b.Deps, d)
+		}
+	}
+	return &b
+}
@@ -0,0 +1,116 @@
+package xmsbom
+
+import (
+	"time"
+)
+
+// cdxBOM is a CycloneDX 1.5 document, see https://cyclonedx.org/docs/1.5/json/.
+type cdxBOM struct {
+	BOMFormat    string           `json:"bomFormat"`
+	SpecVersion  string           `json:"specVersion"`
+	SerialNumber string           `json:"serialNumber"`
+	Version      int              `json:"version"`
+	Metadata     cdxMetadata      `json:"metadata"`
+	Components   []*cdxComponent  `json:"components"`
+	Dependencies []*cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []*cdxComponent `json:"components"`
+	} `json:"tools"`
+	// Component is the examined target if there is only one.
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxComponent struct {
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Type       string        `json:"type"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Pedigree   *cdxPedigree  `json:"pedigree,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxPedigree struct {
+	Ancestors []*cdxComponent `json:"ancestors"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func newCycloneDX(g *graph, tool Tool) *cdxBOM {
+	bom := &cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []*cdxComponent{},
+		Dependencies: []*cdxDependency{},
+	}
+	bom.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []*cdxComponent{{Type: "application", Name: tool.Name, Version: tool.Version}}
+
+	for _, p := range g.roots {
+		c := cdxComponentOf(p)
+		if len(g.roots) == 1 {
+			bom.Metadata.Component = c
+		} else {
+			bom.Components = append(bom.Components, c)
+		}
+	}
+	for _, p := range g.libs {
+		bom.Components = append(bom.Components, cdxComponentOf(p))
+	}
+	for _, p := range append(g.roots, g.libs...) {
+		d := &cdxDependency{Ref: p.ref}
+		for _, dep := range p.deps {
+			d.DependsOn = append(d.DependsOn, dep.ref)
+		}
+		bom.Dependencies = append(bom.Dependencies, d)
+	}
+	return bom
+}
+
+func cdxComponentOf(p *pkg) *cdxComponent {
+	c := &cdxComponent{
+		BOMRef:  p.ref,
+		Type:    "library",
+		Name:    p.name,
+		Version: p.version,
+		PURL:    p.purl,
+	}
+	if p.app {
+		c.Type = "application"
+	}
+	if p.sha256 != "" {
+		c.Hashes = []cdxHash{{Alg: "SHA-256", Content: p.sha256}}
+	}
+	if r := p.replaces; r != nil {
+		c.Pedigree = &cdxPedigree{Ancestors: []*cdxComponent{
+			{Type: "library", Name: r.name, Version: r.version, PURL: r.purl},
+		}}
+	}
+	if p.dir != "" {
+		c.Properties = append(c.Properties, cdxProperty{Name: "goxm:replace:dir", Value: p.dir})
+	}
+	for _, s := range p.settings {
+		c.Properties = append(c.Properties, cdxProperty{Name: "goxm:build:" + s.Key, Value: s.Value})
+	}
+	return c
+}
@@ -0,0 +1,234 @@
+package xmsbom
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmvuln"
+)
+
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+var (
+	Formats = []string{FormatCycloneDX, FormatSPDX}
+
+	errUnknownFormat = errors.New("unknown SBOM format")
+)
+
+// Tool identifies the program generating the SBOM.
+type Tool struct {
+	Name    string
+	Version string
+}
+
+// NewWriter returns a writer producing a single SBOM document of all the targets on Close.
+func NewWriter(w io.Writer, format string, tool Tool) (xmreport.Writer, error) {
+	switch format {
+	case FormatCycloneDX, FormatSPDX:
+		return &writer{w: w, format: format, tool: tool}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", errUnknownFormat, format)
+}
+
+type writer struct {
+	w       io.Writer
+	format  string
+	tool    Tool
+	targets []xmreport.Target
+}
+
+func (w *writer) Write(t xmreport.Target) error {
+	w.targets = append(w.targets, t)
+	return nil
+}
+
+func (w *writer) Close() error {
+	g := newGraph(w.targets)
+	if w.format == FormatSPDX {
+		return encode(w.w, newSPDX(g, w.tool))
+	}
+	return encode(w.w, newCycloneDX(g, w.tool))
+}
+
+// pkg is a package of the SBOM: an examined target, the Go standard library or a module.
+type pkg struct {
+	// ref identifies the package in the document.
+	ref     string
+	app     bool
+	name    string
+	version string
+	purl    string
+	sha256  string
+	// settings are the build settings of a binary.
+	settings []xmreport.Setting
+	deps     []*pkg
+	// replaces is the module replaced by this one.
+	replaces *pkg
+	// dir is the directory of a module replaced with a local one.
+	dir string
+}
+
+// graph holds the packages of all the targets, every module is included once.
+type graph struct {
+	roots []*pkg
+	libs  []*pkg
+	refs  map[string]*pkg
+}
+
+func newGraph(targets []xmreport.Target) *graph {
+	g := &graph{refs: map[string]*pkg{}}
+	for _, t := range targets {
+		if p, ok := t.(*xmreport.Process); ok {
+			t = p.Binary
+		}
+		switch t := t.(type) {
+		case *xmreport.Binary:
+			g.binary(t)
+		case *xmreport.GoMod:
+			g.module(t)
+		}
+	}
+	return g
+}
+
+func (g *graph) binary(b *xmreport.Binary) {
+	if g.refs[b.File] != nil {
+		return
+	}
+	version := b.Main.Version
+	if version == "(devel)" {
+		version = ""
+	}
+	root := &pkg{
+		ref:      b.File,
+		app:      true,
+		name:     b.Path,
+		version:  version,
+		purl:     purl(b.Main.Path, version),
+		settings: b.Settings,
+	}
+	g.add(root)
+	if p := g.stdlib(b.GoVersion); p != nil {
+		root.deps = append(root.deps, p)
+	}
+	for _, d := range b.Deps {
+		root.deps = append(root.deps, g.lib(d))
+	}
+}
+
+func (g *graph) module(m *xmreport.GoMod) {
+	if m.Error != "" || g.refs[m.File] != nil {
+		return
+	}
+	root := &pkg{ref: m.File, app: true, name: m.Path, purl: purl(m.Path, "")}
+	g.add(root)
+	if m.GoVersion != "" {
+		if p := g.stdlib("go" + m.GoVersion); p != nil {
+			root.deps = append(root.deps, p)
+		}
+	}
+	for _, r := range m.Requires {
+		root.deps = append(root.deps, g.lib(r.Module))
+	}
+}
+
+func (g *graph) add(p *pkg) {
+	g.refs[p.ref] = p
+	if p.app {
+		g.roots = append(g.roots, p)
+	} else {
+		g.libs = append(g.libs, p)
+	}
+}
+
+// stdlib returns the package of the standard library of the Go toolchain version, e.g. "go1.22.1".
+func (g *graph) stdlib(goVersion string) *pkg {
+	v := xmvuln.GoVersion(goVersion)
+	if v == "" {
+		return nil
+	}
+	return g.get(&pkg{name: xmvuln.Stdlib, version: v, purl: purl(xmvuln.Stdlib, v)})
+}
+
+func (g *graph) lib(m *xmreport.Module) *pkg {
+	p := &pkg{name: m.Path, version: m.Version, sha256: sha256Hex(m.Sum)}
+	if r := m.Replace; r != nil {
+		orig := &pkg{name: m.Path, version: m.Version, purl: purl(m.Path, m.Version)}
+		orig.ref = orig.purl
+		if r.Version == "" {
+			// Local directories have no module path or version of their own.
+			p = &pkg{name: m.Path, dir: r.Path}
+		} else {
+			p = &pkg{name: r.Path, version: r.Version, sha256: sha256Hex(r.Sum)}
+		}
+		p.replaces = orig
+	}
+	p.purl = purl(p.name, p.version)
+	return g.get(p)
+}
+
+// get returns the package added before with the same ref, or adds the given one.
+func (g *graph) get(p *pkg) *pkg {
+	p.ref = p.purl
+	if p.dir != "" {
+		p.ref += "#" + p.dir
+	}
+	if found := g.refs[p.ref]; found != nil {
+		return found
+	}
+	g.add(p)
+	return p
+}
+
+// purl returns the package URL of the Go module, see
+// https://github.com/package-url/purl-spec/blob/main/PURL-TYPES.rst#golang.
+func purl(modpath, version string) string {
+	segs := strings.Split(modpath, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	p := "pkg:golang/" + strings.Join(segs, "/")
+	if version != "" {
+		p += "@" + url.PathEscape(version)
+	}
+	return p
+}
+
+// sha256Hex converts an "h1:" module hash to the hex encoded SHA-256 digest it consists of.
+func sha256Hex(sum string) string {
+	b64, ok := strings.CutPrefix(sum, "h1:")
+	if !ok {
+		return ""
+	}
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(b) != 32 {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func encode(w io.Writer, doc any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,164 @@
+package xmsbom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// spdxDocument is an SPDX 2.3 document, see https://spdx.github.io/spdx-spec/v2.3/.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []*spdxPackage     `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string           `json:"name"`
+	SPDXID                string           `json:"SPDXID"`
+	VersionInfo           string           `json:"versionInfo,omitempty"`
+	DownloadLocation      string           `json:"downloadLocation"`
+	FilesAnalyzed         bool             `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum   `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternal   `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string           `json:"primaryPackagePurpose,omitempty"`
+	Comment               string           `json:"comment,omitempty"`
+	Annotations           []spdxAnnotation `json:"annotations,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternal struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxAnnotation struct {
+	AnnotationDate string `json:"annotationDate"`
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	Comment        string `json:"comment"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const spdxDocumentID = "SPDXRef-DOCUMENT"
+
+var spdxIDInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+func newSPDX(g *graph, tool Tool) *spdxDocument {
+	now := time.Now().UTC().Format(time.RFC3339)
+	creator := "Tool: " + tool.Name + "-" + tool.Version
+
+	name := tool.Name
+	if len(g.roots) == 1 {
+		name = g.roots[0].name
+	}
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: "https://github.com/o7q2ab/goxm/spdx/" + url.PathEscape(name) + "-" + newUUID(),
+		CreationInfo:      spdxCreationInfo{Created: now, Creators: []string{creator}},
+		Packages:          []*spdxPackage{},
+		Relationships:     []spdxRelationship{},
+	}
+
+	ids := map[string]string{}
+	used := map[string]bool{}
+	id := func(p *pkg) string {
+		if id, ok := ids[p.ref]; ok {
+			return id
+		}
+		name := p.name
+		if p.version != "" {
+			name += "-" + p.version
+		}
+		base := "SPDXRef-Package-" + spdxIDInvalid.ReplaceAllString(name, "-")
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		ids[p.ref], used[id] = id, true
+		return id
+	}
+	added := map[string]bool{}
+	add := func(p *pkg) {
+		if added[p.ref] {
+			return
+		}
+		added[p.ref] = true
+		sp := &spdxPackage{
+			Name:             p.name,
+			SPDXID:           id(p),
+			VersionInfo:      p.version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs:     []spdxExternal{{"PACKAGE-MANAGER", "purl", p.purl}},
+		}
+		if p.app {
+			sp.PrimaryPackagePurpose = "APPLICATION"
+			sp.Comment = "examined file: " + p.ref
+		} else {
+			sp.PrimaryPackagePurpose = "LIBRARY"
+		}
+		if p.sha256 != "" {
+			sp.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: p.sha256}}
+		}
+		if p.dir != "" {
+			sp.Comment = "replaced with the local directory " + p.dir
+		}
+		for _, s := range p.settings {
+			sp.Annotations = append(sp.Annotations, spdxAnnotation{
+				AnnotationDate: now,
+				AnnotationType: "OTHER",
+				Annotator:      creator,
+				Comment:        "build setting: " + s.Key + "=" + s.Value,
+			})
+		}
+		doc.Packages = append(doc.Packages, sp)
+	}
+	relate := func(a, typ, b string) {
+		doc.Relationships = append(doc.Relationships, spdxRelationship{a, typ, b})
+	}
+
+	for _, p := range g.roots {
+		add(p)
+		relate(spdxDocumentID, "DESCRIBES", id(p))
+	}
+	for _, p := range g.libs {
+		add(p)
+		if r := p.replaces; r != nil && r.ref != p.ref {
+			if found := g.refs[r.ref]; found != nil {
+				r = found
+			}
+			add(r)
+			relate(id(p), "VARIANT_OF", id(r))
+		}
+	}
+	for _, p := range g.roots {
+		for _, dep := range p.deps {
+			relate(id(p), "DEPENDS_ON", id(dep))
+		}
+	}
+	return doc
+}
@@ -0,0 +1,32 @@
+package xmsource
+
+import "bytes"
+
+// magics are the leading bytes of the executable formats Go builds for.
+var magics = [][]byte{
+	[]byte("\x7fELF"),
+	// PE, the "MZ" header of the DOS stub.
+	[]byte("MZ"),
+	// Mach-O, 32 and 64 bit in both byte orders, and universal binaries.
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+	// XCOFF, 32 and 64 bit.
+	{0x01, 0xdf},
+	{0x01, 0xf7},
+	[]byte("\x00asm"),
+}
+
+// HasExecutableMagic reports whether the leading bytes of a file are the magic number
+// of an executable format: ELF, PE, Mach-O, XCOFF or WebAssembly. It is a cheap check
+// before reading the build info, which may parse much of the file.
+func HasExecutableMagic(b []byte) bool {
+	for _, m := range magics {
+		if bytes.HasPrefix(b, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package xmsource
+
+import (
+	"bytes"
+	"cmp"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/o7q2ab/goxm/internal/xmexe"
+	"github.com/o7q2ab/goxm/internal/xmreport"
+	"github.com/o7q2ab/goxm/internal/xmsym"
+)
+
+// Stdin is the name standing for the standard input.
+const Stdin = "-"
+
+var errNotExecutable = errors.New("not an executable file")
+
+// Source is the contents of a file to examine: a file on the disk, the standard input,
+// or e.g. an entry of an archive read into memory.
+type Source struct {
+	// Name is the name of the file displayed in the output.
+	Name string
+	Size int64
+	// ModTime is zero if it is unknown.
+	ModTime time.Time
+
+	r     io.ReaderAt
+	close func() error
+}
+
+// Open opens the file, or the standard input for Stdin.
+func Open(name string) (*Source, error) {
+	if name == Stdin {
+		return openStdin()
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Source{Name: name, Size: stat.Size(), ModTime: stat.ModTime(), r: f, close: f.Close}, nil
+}
+
+// openStdin returns the standard input. Unless it is redirected from a file, it cannot
+// be read at random offsets and it is spooled to a temporary file first.
+func openStdin() (*Source, error) {
+	const name = "<stdin>"
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode().IsRegular() {
+		return &Source{Name: name, Size: stat.Size(), ModTime: stat.ModTime(), r: os.Stdin}, nil
+	}
+
+	f, err := os.CreateTemp("", "goxm-stdin-")
+	if err != nil {
+		return nil, err
+	}
+	remove := func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	}
+	n, err := io.Copy(f, os.Stdin)
+	if err != nil {
+		remove()
+		return nil, err
+	}
+	return &Source{Name: name, Size: n, r: f, close: remove}, nil
+}
+
+// New returns the source of the contents read into memory.
+func New(name string, data []byte, mtime time.Time) *Source {
+	return &Source{Name: name, Size: int64(len(data)), ModTime: mtime, r: bytes.NewReader(data)}
+}
+
+// NewReaderAt returns the source reading the contents from r, which is not closed by Close.
+func NewReaderAt(name string, r io.ReaderAt, size int64, mtime time.Time) *Source {
+	return &Source{Name: name, Size: size, ModTime: mtime, r: r}
+}
+
+func (s *Source) ReadAt(p []byte, off int64) (int, error) {
+	return s.r.ReadAt(p, off)
+}
+
+// Reader returns a reader of the contents from the start.
+func (s *Source) Reader() *io.SectionReader {
+	return io.NewSectionReader(s.r, 0, s.Size)
+}
+
+// Head returns up to n leading bytes of the contents.
+func (s *Source) Head(n int) []byte {
+	b := make([]byte, min(int64(n), s.Size))
+	k, _ := s.r.ReadAt(b, 0)
+	return b[:k]
+}
+
+func (s *Source) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// Options select what is read from the Go binaries besides their build info.
+type Options struct {
+	// Packages reads the packages linked into the binary from its symbol table.
+	Packages bool
+	// Files reads the source files of the packages too, it implies Packages.
+	Files bool
+	// Hardening reads the security properties of the executable.
+	Hardening bool
+}
+
+// ReadBinary reads the build info of the Go binary.
+func ReadBinary(s *Source, opts Options) (*xmreport.Binary, error) {
+	if !HasExecutableMagic(s.Head(4)) {
+		return nil, fmt.Errorf("%s: %w", s.Name, errNotExecutable)
+	}
+	info, err := buildinfo.Read(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not read Go build info from %s: %w", s.Name, err)
+	}
+	b := xmreport.NewBinary(s.Name, s.Size, s.ModTime, info)
+	if opts.Packages || opts.Files {
+		readPackages(s, b, opts.Files)
+	}
+	if opts.Hardening {
+		readHardening(s, b)
+	}
+	return b, nil
+}
+
+func readHardening(s *Source, b *xmreport.Binary) {
+	h, err := xmexe.Inspect(s)
+	if err != nil {
+		b.HardeningError = err.Error()
+		return
+	}
+	h.BuildMode = "exe"
+	if mode, ok := b.Setting("-buildmode"); ok {
+		h.BuildMode = mode
+	}
+	trimpath, _ := b.Setting("-trimpath")
+	h.Trimpath = trimpath == "true"
+	b.Hardening = h
+}
+
+// readPackages reads the packages of the binary, grouped by their modules in the
+// order of the main module, the dependencies and the standard library.
+func readPackages(s *Source, b *xmreport.Binary, files bool) {
+	t, err := xmsym.Read(s)
+	if err != nil {
+		b.PackagesError = err.Error()
+		return
+	}
+	order := map[string]int{b.Main.Path: 0}
+	for i, d := range b.Deps {
+		order[d.Path] = i + 1
+	}
+	order[xmreport.StdModule] = len(b.Deps) + 1
+
+	for path, pkgFiles := range t.Packages() {
+		p := &xmreport.Package{Path: path, Module: b.ModuleOf(path)}
+		if files {
+			p.Files = pkgFiles
+		}
+		b.Packages = append(b.Packages, p)
+	}
+	slices.SortFunc(b.Packages, func(x, y *xmreport.Package) int {
+		return cmp.Or(
+			cmp.Compare(moduleOrder(order, x.Module), moduleOrder(order, y.Module)),
+			strings.Compare(x.Path, y.Path),
+		)
+	})
+}
+
+// moduleOrder returns the position of the module, the unknown ones are the last.
+func moduleOrder(order map[string]int, mod string) int {
+	if i, ok := order[mod]; ok && mod != "" {
+		return i
+	}
+	return len(order)
+}
+
+// ReadStream reads the build info of the Go binary streamed from r, e.g. an entry of an
+// archive, into memory. It returns nil if the contents are not a Go binary, and only
+// their leading bytes are read unless they are the magic number of an executable.
+func ReadStream(name string, r io.Reader, mtime time.Time, opts Options) (*xmreport.Binary, error) {
+	head := make([]byte, 4)
+	_, err := io.ReadFull(r, head)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// The contents are too short to be a binary.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !HasExecutableMagic(head) {
+		return nil, nil
+	}
+	data, err := io.ReadAll(io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return nil, err
+	}
+	b, err := ReadBinary(New(name, data, mtime), opts)
+	if err != nil {
+		return nil, nil
+	}
+	return b, nil
+}
@@ -0,0 +1,366 @@
+package xmsym
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// The read-only data of a Go binary is mostly the pclntab, the funcdata of the
+// functions and the type descriptors, none of them split into symbols. They are
+// attributed to the packages here by decoding them as the runtime does.
+
+// pcHeader is the header of the pclntab since Go 1.18, see runtime/symtab.go.
+type pcHeader struct {
+	order   binary.ByteOrder
+	ptrSize int
+	// funcSize is the size of the fixed part of a function entry.
+	funcSize int
+	nfunc    int
+	// The offsets of the tables in the pclntab.
+	funcnames, pctab, functab int
+}
+
+func readPCHeader(data []byte) *pcHeader {
+	if len(data) < 8 || data[4] != 0 || data[5] != 0 {
+		return nil
+	}
+	h := &pcHeader{ptrSize: int(data[7]), funcSize: 44}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(data) {
+		case 0xfffffff1:
+			h.order = order
+		case 0xfffffff0:
+			// Go 1.18 and 1.19 have no start line of the functions.
+			h.order, h.funcSize = order, 40
+		}
+	}
+	if h.order == nil || h.ptrSize != 4 && h.ptrSize != 8 || len(data) < 8+8*h.ptrSize {
+		return nil
+	}
+	word := func(i int) int {
+		off := 8 + i*h.ptrSize
+		if h.ptrSize == 8 {
+			return int(h.order.Uint64(data[off:]))
+		}
+		return int(h.order.Uint32(data[off:]))
+	}
+	// The number of files and the start of the text are skipped, and so are the
+	// compilation units and the files tables, which are shared by the functions.
+	h.nfunc, h.funcnames, h.pctab, h.functab = word(0), word(3), word(6), word(7)
+	if h.nfunc <= 0 || h.functab+8*h.nfunc > len(data) || h.funcnames > len(data) || h.pctab > len(data) {
+		return nil
+	}
+	return h
+}
+
+// funcSymbols returns the bytes of the pclntab and of the funcdata taken by every
+// function, as symbols named after the functions. The tables shared by several
+// functions are attributed to the first one. The funcdata is skipped if it is nil.
+func funcSymbols(pclntab, funcdata []byte) []*Symbol {
+	h := readPCHeader(pclntab)
+	if h == nil {
+		return nil
+	}
+	u32 := func(off int) uint32 {
+		if off < 0 || off+4 > len(pclntab) {
+			return 0
+		}
+		return h.order.Uint32(pclntab[off:])
+	}
+
+	type fn struct {
+		name     string
+		size     int64
+		funcdata []uint32
+	}
+	funcs := make([]fn, 0, h.nfunc)
+	seen := map[uint32]bool{}
+	var offsets []uint32
+	for i := range h.nfunc {
+		// The function table holds the offsets of the text and of the entry of every function.
+		off := h.functab + int(u32(h.functab+8*i+4))
+		if off+h.funcSize > len(pclntab) {
+			return nil
+		}
+		name := cString(pclntab, h.funcnames+int(int32(u32(off+4))))
+		npcdata, nfuncdata := int(u32(off+28)), int(pclntab[off+h.funcSize-1])
+		f := fn{name: name, size: int64(8 + h.funcSize + 4*npcdata + 4*nfuncdata + len(name) + 1)}
+
+		// The pcsp, pcfile and pcln tables are followed by the pcdata offsets.
+		tables := []int{off + 16, off + 20, off + 24}
+		for j := range npcdata {
+			tables = append(tables, off+h.funcSize+4*j)
+		}
+		for _, t := range tables {
+			if v := u32(t); v != 0 && !seen[v] {
+				seen[v] = true
+				f.size += int64(pcvalueSize(pclntab, h.pctab+int(v)))
+			}
+		}
+		for j := range nfuncdata {
+			f.funcdata = append(f.funcdata, u32(off+h.funcSize+4*npcdata+4*j))
+		}
+		offsets = append(offsets, f.funcdata...)
+		funcs = append(funcs, f)
+	}
+
+	// The funcdata has no sizes, they are the distances to the next ones.
+	var sizes map[uint32]int64
+	if funcdata != nil {
+		sizes = map[uint32]int64{}
+		offsets = slices.Compact(slices.Sorted(slices.Values(offsets)))
+		for i, off := range offsets {
+			end := uint64(len(funcdata))
+			if i+1 < len(offsets) {
+				end = min(end, uint64(offsets[i+1]))
+			}
+			if uint64(off) < end {
+				sizes[off] = int64(end - uint64(off))
+			}
+		}
+	}
+
+	syms := make([]*Symbol, 0, len(funcs))
+	for _, f := range funcs {
+		for _, off := range f.funcdata {
+			f.size += sizes[off]
+			delete(sizes, off)
+		}
+		syms = append(syms, &Symbol{Name: f.name, Kind: KindRodata, Size: f.size})
+	}
+	return syms
+}
+
+// pcvalueSize returns the size of the table of values at off: pairs of a value
+// delta and a pc delta, ending with a zero value delta.
+func pcvalueSize(data []byte, off int) int {
+	if off < 0 || off >= len(data) {
+		return 0
+	}
+	n := 0
+	for first := true; ; first = false {
+		delta, k := binary.Uvarint(data[off+n:])
+		if k <= 0 {
+			return n
+		}
+		n += k
+		if delta == 0 && !first {
+			return n
+		}
+		if _, k = binary.Uvarint(data[off+n:]); k <= 0 {
+			return n
+		}
+		n += k
+	}
+}
+
+func cString(data []byte, off int) string {
+	if off < 0 || off >= len(data) {
+		return ""
+	}
+	end := slices.Index(data[off:], 0)
+	if end < 0 {
+		return ""
+	}
+	return string(data[off : off+end])
+}
+
+// Kinds of the types and the flags of the type descriptors, see internal/abi/type.go.
+const (
+	kindArray     = 17
+	kindChan      = 18
+	kindFunc      = 19
+	kindInterface = 20
+	kindMap       = 21
+	kindPointer   = 22
+	kindSlice     = 23
+	kindStruct    = 25
+	kindMask      = 0x1f
+
+	tflagUncommon  = 1 << 0
+	tflagExtraStar = 1 << 1
+)
+
+// typeSymbols finds the type descriptors in the data between runtime.types and
+// runtime.etypes, and returns them as symbols named like "type:*http.Client". The
+// sizes of the descriptors are the distances to the next ones, which takes in the
+// fields and the methods following them, plus the same for their names, which are
+// packed apart.
+//
+// The descriptors are not listed anywhere, so they are looked for at every aligned
+// offset and told by the offsets of their names, which must point to valid names.
+// The types with methods belong to the packages of their uncommon parts, the pointers
+// to them to the same packages, and the other unnamed types are not attributed.
+// The itabs among them are told by their interface and type pointers, which must
+// point to the descriptors at addr, and belong to the packages of their types.
+func typeSymbols(types []byte, addr uint64, h *pcHeader) []*Symbol {
+	p := h.ptrSize
+	// The size of the common part: the sizes, hash, flags, equal function, GC data and the offsets.
+	header := 4*p + 16
+	ptr := func(off int) uint64 {
+		if p == 8 {
+			return h.order.Uint64(types[off:])
+		}
+		return uint64(h.order.Uint32(types[off:]))
+	}
+	i32 := func(off int) int {
+		if off < 0 || off+4 > len(types) {
+			return 0
+		}
+		return int(int32(h.order.Uint32(types[off:])))
+	}
+
+	type desc struct {
+		off  int
+		name string
+		pkg  string
+		kind byte
+		itab bool
+		// str is the offset of the name, and owner the descriptor of the name if this
+		// is one.
+		str   int
+		owner *desc
+		size  int
+		// ptrToThis is the offset of the pointer type to this one, or zero.
+		ptrToThis int
+	}
+	var descs []*desc
+	byOff := map[int]*desc{}
+	for off := 0; off+header <= len(types); off += p {
+		size, ptrBytes := ptr(off), ptr(off+p)
+		tflag, align, fieldAlign, kind := types[off+2*p+4], types[off+2*p+5], types[off+2*p+6], types[off+2*p+7]&kindMask
+		if ptrBytes > size || tflag >= 1<<6 || !powerOfTwo(align) || !powerOfTwo(fieldAlign) || kind == 0 || kind > 26 {
+			continue
+		}
+		str := i32(off + 4*p + 8)
+		name, ok := typeName(types, str)
+		if !ok || tflag&tflagExtraStar != 0 && !strings.HasPrefix(name, "*") {
+			continue
+		}
+		ptrToThis := i32(off + 4*p + 12)
+		if ptrToThis < 0 || ptrToThis >= len(types) || ptrToThis%p != 0 {
+			continue
+		}
+		if tflag&tflagExtraStar != 0 {
+			name = name[1:]
+		}
+		d := &desc{off: off, name: name, kind: kind, str: str, ptrToThis: ptrToThis}
+		if tflag&tflagUncommon != 0 {
+			if u := uncommonOffset(off, header, p, kind); u >= 0 {
+				d.pkg, _ = typeName(types, i32(u))
+			}
+			// The shapes of the generic functions belong to no package.
+			if d.pkg == "go.shape" {
+				d.pkg = ""
+			}
+		}
+		descs = append(descs, d)
+		byOff[off] = d
+		// The next descriptor cannot start inside the common part of this one.
+		off += header - p
+	}
+
+	for _, d := range descs {
+		if ptr := byOff[d.ptrToThis]; d.pkg != "" && d.ptrToThis != 0 && ptr != nil && ptr.pkg == "" {
+			ptr.pkg, ptr.name = d.pkg, "*"+d.name
+		}
+	}
+
+	// An itab starts with the interface and the type, and the hash of the type.
+	target := func(off int) *desc {
+		v := ptr(off)
+		if v < addr || v-addr >= uint64(len(types)) {
+			return nil
+		}
+		return byOff[int(v-addr)]
+	}
+	var itabs []*desc
+	for off := 0; off+3*p <= len(types); off += p {
+		inter, typ := target(off), target(off+p)
+		if inter == nil || typ == nil || inter.kind != kindInterface ||
+			h.order.Uint32(types[off+2*p:]) != h.order.Uint32(types[typ.off+2*p:]) {
+			continue
+		}
+		itabs = append(itabs, &desc{off: off, name: typ.name + "," + inter.name, pkg: typ.pkg, itab: true})
+	}
+	// The names shared by several descriptors go to the first one.
+	var names []*desc
+	for _, d := range descs {
+		if byOff[d.str] == nil {
+			byOff[d.str] = &desc{off: d.str, owner: d}
+			names = append(names, byOff[d.str])
+		}
+	}
+	all := slices.Concat(descs, itabs, names)
+	slices.SortFunc(all, func(a, b *desc) int { return a.off - b.off })
+	for i, d := range all {
+		end := len(types)
+		if i+1 < len(all) {
+			end = all[i+1].off
+		}
+		if d.owner != nil {
+			d.owner.size += end - d.off
+		} else {
+			d.size += end - d.off
+		}
+	}
+
+	syms := make([]*Symbol, 0, len(descs)+len(itabs))
+	for _, d := range slices.Concat(descs, itabs) {
+		name := "type:" + d.name
+		if d.itab {
+			name = "go:itab." + d.name
+		}
+		syms = append(syms, &Symbol{Name: name, Kind: KindRodata, Size: int64(d.size), typ: true, pkg: d.pkg})
+	}
+	return syms
+}
+
+// uncommonOffset returns the offset of the uncommon part of the descriptor, which
+// follows the part specific to its kind, or -1 if it is unknown.
+func uncommonOffset(off, header, p int, kind byte) int {
+	extra := 0
+	switch kind {
+	case kindArray:
+		extra = 3 * p
+	case kindChan:
+		extra = 2 * p
+	case kindFunc:
+		// The counts of the parameters and the results, aligned.
+		extra = p
+		if p == 4 {
+			extra = 4
+		}
+	case kindInterface, kindStruct:
+		extra = 4 * p
+	case kindPointer, kindSlice:
+		extra = p
+	case kindMap:
+		// The layout of the map types changes between the Go versions.
+		return -1
+	}
+	return off + header + extra
+}
+
+// typeName returns the name at the offset in the type descriptors: a byte of
+// flags followed by the varint length of the name and the name itself.
+func typeName(types []byte, off int) (string, bool) {
+	if off <= 0 || off >= len(types) || types[off] >= 1<<4 {
+		return "", false
+	}
+	n, k := binary.Uvarint(types[off+1:])
+	if k <= 0 || n == 0 || n > 1<<12 || off+1+k+int(n) > len(types) {
+		return "", false
+	}
+	name := string(types[off+1+k : off+1+k+int(n)])
+	if !utf8.ValidString(name) || strings.ContainsFunc(name, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return "", false
+	}
+	return name, true
+}
+
+func powerOfTwo(n byte) bool {
+	return n != 0 && n&(n-1) == 0
+}
@@ -0,0 +1,349 @@
+package xmsym
+
+import (
+	"cmp"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"debug/pe"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Kinds of the sections, by what they hold.
+const (
+	KindText   = "text"
+	KindRodata = "rodata"
+	KindData   = "data"
+)
+
+// Kinds are the kinds of the sections, in the order they are usually laid out.
+var Kinds = []string{KindText, KindRodata, KindData}
+
+// Layout describes how the bytes of the sections of a binary are taken by its symbols.
+// The sections without data in the file, like the ones of the zeroed data and the
+// debug info, are not included.
+type Layout struct {
+	// Sections are the total sizes of the sections by their kinds.
+	Sections map[string]int64
+	Symbols  []*Symbol
+	// Stripped is set if the binary has no symbol table. The symbols are the
+	// functions from the pclntab then, and only their text and pclntab are attributed.
+	Stripped bool
+}
+
+type Symbol struct {
+	Name string
+	// Kind is the kind of the section the symbol is in.
+	Kind string
+	Size int64
+	// typ is set for the type descriptors decoded from the data, whose names are
+	// qualified by the package names rather than paths. pkg is their package then.
+	typ bool
+	pkg string
+}
+
+// Package returns the path of the package the symbol belongs to, or "" if it is
+// generated by the compiler or the linker, e.g. the string literals. The type
+// descriptors and the itabs belong to the packages of their named types.
+func (s *Symbol) Package() string {
+	if s.typ {
+		return s.pkg
+	}
+	for _, prefix := range []string{"type:", "go:itab.", "type.", "go.itab."} {
+		if name, ok := strings.CutPrefix(s.Name, prefix); ok {
+			return typePackage(name)
+		}
+	}
+	if generated(s.Name) {
+		return ""
+	}
+	return packageName(s.Name)
+}
+
+func packageName(name string) string {
+	sym := gosym.Sym{Name: name}
+	if pkg := sym.PackageName(); pkg != "_" {
+		return pkg
+	}
+	return ""
+}
+
+// typePackage returns the package of the named type of the descriptor, e.g. "net/http"
+// of "*net/http.Client", or "" for the unnamed types.
+func typePackage(name string) string {
+	// The itabs are named by the type and the interface.
+	name, _, _ = strings.Cut(name, ",")
+	name = strings.TrimLeft(name, "*[]0123456789")
+	if name == "" || name[0] == '.' || strings.HasPrefix(name, "map[") || strings.ContainsAny(name, " (") {
+		return ""
+	}
+	return packageName(name)
+}
+
+// generated reports whether the symbol is generated by the compiler or the linker.
+func generated(name string) bool {
+	if strings.HasPrefix(name, "go:") || strings.HasPrefix(name, "type:") || strings.HasPrefix(name, "type.") {
+		return true
+	}
+	// Before Go 1.20 the prefix was "go.", which also starts the paths like "go.uber.org/zap".
+	if rest, ok := strings.CutPrefix(name, "go."); ok {
+		host, _, found := strings.Cut(rest, "/")
+		return !found || strings.ContainsFunc(host, func(r rune) bool {
+			return r != '.' && r != '-' && (r < 'a' || r > 'z') && (r < '0' || r > '9')
+		})
+	}
+	return false
+}
+
+// ReadLayout reads the sections and the symbols of the binary. The pclntab, the
+// funcdata and the type descriptors are split into the symbols of the functions and
+// the types they describe, see funcSymbols and typeSymbols.
+func ReadLayout(r io.ReaderAt) (*Layout, error) {
+	exe, err := openExe(r)
+	if err != nil {
+		return nil, err
+	}
+	sections := exe.sections()
+	l := &Layout{Sections: map[string]int64{}}
+	for _, s := range sections {
+		l.Sections[s.kind] += int64(s.size)
+	}
+
+	byName := map[string]symbol{}
+	for _, s := range exe.symbols(sections) {
+		l.Symbols = append(l.Symbols, &Symbol{Name: s.name, Kind: sections[s.section].kind, Size: int64(s.size)})
+		byName[s.name] = s
+	}
+	t, err := readTable(exe)
+	if len(l.Symbols) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		l.Stripped = true
+		for _, f := range t.Funcs {
+			l.Symbols = append(l.Symbols, &Symbol{Name: f.Name, Kind: KindText, Size: int64(f.End - f.Entry)})
+		}
+	}
+	if t == nil {
+		return l, nil
+	}
+
+	var funcdata []byte
+	for _, name := range []string{"go:func.*", "go.func.*"} {
+		if s, ok := byName[name]; ok && s.size != 0 {
+			funcdata = exe.read(s.addr, s.size)
+		}
+	}
+	l.Symbols = append(l.Symbols, funcSymbols(t.data, funcdata)...)
+
+	start, ok := byName["runtime.types"]
+	end, eok := byName["runtime.etypes"]
+	if h := readPCHeader(t.data); h != nil && ok && eok && start.addr < end.addr {
+		if types := exe.read(start.addr, end.addr-start.addr); types != nil {
+			l.Symbols = append(l.Symbols, typeSymbols(types, start.addr, h)...)
+		}
+	}
+	return l, nil
+}
+
+type section struct {
+	kind       string
+	addr, size uint64
+}
+
+type symbol struct {
+	name string
+	// section is the index of the section in the ones returned by exe.sections.
+	section    int
+	addr, size uint64
+}
+
+// markers are the symbols the Go linker puts at the bounds of the data, e.g. of the
+// pclntab. They take no bytes themselves.
+var markers = map[string]bool{
+	"runtime.text": true, "runtime.etext": true,
+	"runtime.rodata": true, "runtime.erodata": true,
+	"runtime.types": true, "runtime.etypes": true,
+	"runtime.typelink": true, "runtime.etypelink": true,
+	"runtime.itablink": true, "runtime.eitablink": true,
+	"runtime.pclntab": true, "runtime.epclntab": true,
+	"runtime.noptrdata": true, "runtime.enoptrdata": true,
+	"runtime.data": true, "runtime.edata": true,
+	"runtime.bss": true, "runtime.ebss": true,
+	"runtime.noptrbss": true, "runtime.enoptrbss": true,
+	"runtime.end": true,
+}
+
+// fillSizes sets the sizes of the symbols to the distances to the next ones in
+// their sections, for the formats whose symbol tables have no sizes.
+func fillSizes(syms []symbol, sections []section) {
+	slices.SortFunc(syms, func(a, b symbol) int {
+		return cmp.Or(cmp.Compare(a.section, b.section), cmp.Compare(a.addr, b.addr))
+	})
+	for i := range syms {
+		s := &syms[i]
+		if markers[s.name] {
+			continue
+		}
+		// The zeroed data may follow the data in the file of the same section.
+		end := sections[s.section].addr + sections[s.section].size
+		if i+1 < len(syms) && syms[i+1].section == s.section {
+			end = min(end, syms[i+1].addr)
+		}
+		if end > s.addr {
+			s.size = end - s.addr
+		}
+	}
+}
+
+func (e *elfExe) sections() []section {
+	var sections []section
+	for _, s := range e.f.Sections {
+		if s.Flags&elf.SHF_ALLOC == 0 || s.Type == elf.SHT_NOBITS {
+			continue
+		}
+		kind := KindRodata
+		switch {
+		case s.Flags&elf.SHF_EXECINSTR != 0:
+			kind = KindText
+		case s.Flags&elf.SHF_WRITE != 0:
+			kind = KindData
+		}
+		sections = append(sections, section{kind: kind, addr: s.Addr, size: s.Size})
+	}
+	return sections
+}
+
+func (e *elfExe) symbols(sections []section) []symbol {
+	syms, err := e.f.Symbols()
+	if err != nil {
+		return nil
+	}
+	// The indexes of the sections in the file to the ones of the returned sections.
+	index := map[elf.SectionIndex]int{}
+	for i, s := range e.f.Sections {
+		if s.Flags&elf.SHF_ALLOC != 0 && s.Type != elf.SHT_NOBITS {
+			index[elf.SectionIndex(i)] = len(index)
+		}
+	}
+	var found []symbol
+	for _, s := range syms {
+		typ := elf.ST_TYPE(s.Info)
+		i, ok := index[s.Section]
+		if !ok || typ != elf.STT_FUNC && typ != elf.STT_OBJECT {
+			continue
+		}
+		found = append(found, symbol{name: s.Name, section: i, addr: s.Value, size: s.Size})
+	}
+	return found
+}
+
+// sectionKind returns the kind of the Mach-O section, or "" if it has no data in the file.
+func (m *machoExe) sectionKind(s *macho.Section) string {
+	if s.Flags&sectionType == zerofill {
+		return ""
+	}
+	switch s.Seg {
+	case "__TEXT":
+		if s.Name == "__text" {
+			return KindText
+		}
+		return KindRodata
+	case "__DATA_CONST":
+		return KindRodata
+	case "__DATA":
+		return KindData
+	}
+	return ""
+}
+
+func (m *machoExe) sections() []section {
+	var sections []section
+	for _, s := range m.f.Sections {
+		if kind := m.sectionKind(s); kind != "" {
+			sections = append(sections, section{kind: kind, addr: s.Addr, size: s.Size})
+		}
+	}
+	return sections
+}
+
+func (m *machoExe) symbols(sections []section) []symbol {
+	if m.f.Symtab == nil {
+		return nil
+	}
+	// The numbers of the sections in the file, from 1, to the indexes of the returned ones.
+	index := map[uint8]int{}
+	for i, s := range m.f.Sections {
+		if m.sectionKind(s) != "" {
+			index[uint8(i+1)] = len(index)
+		}
+	}
+	// The symbols with any of these type bits set are debugging entries.
+	const stab = 0xe0
+	var found []symbol
+	for _, s := range m.f.Symtab.Syms {
+		if i, ok := index[s.Sect]; ok && s.Type&stab == 0 {
+			found = append(found, symbol{name: strings.TrimPrefix(s.Name, "_"), section: i, addr: s.Value})
+		}
+	}
+	fillSizes(found, sections)
+	return found
+}
+
+// sectionKind returns the kind of the PE section, or "" if it has no data in the file.
+func (p *peExe) sectionKind(s *pe.Section) string {
+	const (
+		uninitialized = 0x00000080
+		discardable   = 0x02000000
+	)
+	switch c := s.Characteristics; {
+	case c&(uninitialized|discardable) != 0 || s.Size == 0:
+		return ""
+	case c&pe.IMAGE_SCN_MEM_EXECUTE != 0:
+		return KindText
+	case c&pe.IMAGE_SCN_MEM_WRITE != 0:
+		return KindData
+	}
+	return KindRodata
+}
+
+func (p *peExe) sections() []section {
+	var sections []section
+	for _, s := range p.f.Sections {
+		if kind := p.sectionKind(s); kind != "" {
+			// The raw size is rounded up to the file alignment.
+			size := s.Size
+			if s.VirtualSize != 0 {
+				size = min(size, s.VirtualSize)
+			}
+			sections = append(sections, section{kind: kind, addr: uint64(s.VirtualAddress), size: uint64(size)})
+		}
+	}
+	return sections
+}
+
+func (p *peExe) symbols(sections []section) []symbol {
+	index := map[int16]int{}
+	for i, s := range p.f.Sections {
+		if p.sectionKind(s) != "" {
+			index[int16(i+1)] = len(index)
+		}
+	}
+	const (
+		external = 2
+		static   = 3
+	)
+	var found []symbol
+	for _, s := range p.f.Symbols {
+		i, ok := index[s.SectionNumber]
+		if !ok || s.StorageClass != external && s.StorageClass != static || strings.HasPrefix(s.Name, ".") {
+			continue
+		}
+		// The values are the offsets in the sections.
+		found = append(found, symbol{name: s.Name, section: i, addr: sections[i].addr + uint64(s.Value)})
+	}
+	fillSizes(found, sections)
+	return found
+}
@@ -0,0 +1,362 @@
+// Package xmsym reads the symbol tables of Go binaries.
+package xmsym
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var (
+	errUnknownFormat = errors.New("unknown executable format")
+	errNoPclntab     = errors.New("no Go symbol table found")
+)
+
+// Table is the symbol table of a Go binary, decoded from its pclntab.
+type Table struct {
+	*gosym.Table
+	// data is the pclntab, possibly followed by other data if it was searched for.
+	data []byte
+}
+
+// Read reads the symbol table of the Go binary. The pclntab is looked up by the
+// name of its section or symbol, and searched for by its header in the data of
+// the binary if it was stripped of them.
+func Read(r io.ReaderAt) (*Table, error) {
+	exe, err := openExe(r)
+	if err != nil {
+		return nil, err
+	}
+	return readTable(exe)
+}
+
+func readTable(exe exe) (*Table, error) {
+	if data, text := exe.pclntab(); data != nil {
+		if t, err := newTable(data, text); err == nil {
+			return t, nil
+		}
+	}
+	for _, data := range exe.blocks() {
+		if t := search(data, exe.text()); t != nil {
+			return t, nil
+		}
+	}
+	return nil, errNoPclntab
+}
+
+// Packages returns the paths of the packages the functions of the binary belong
+// to, and the source files of these functions.
+func (t *Table) Packages() map[string][]string {
+	files := map[string]map[string]bool{}
+	for i := range t.Funcs {
+		f := &t.Funcs[i]
+		pkg := f.PackageName()
+		// The closures of some runtime functions are named like "_.goready.func1".
+		if pkg == "" || pkg == "_" {
+			continue
+		}
+		if files[pkg] == nil {
+			files[pkg] = map[string]bool{}
+		}
+		// The wrappers generated by the compiler have the file "<autogenerated>".
+		if file, _, _ := t.PCToLine(f.Entry); file != "" && !strings.HasPrefix(file, "<") {
+			files[pkg][file] = true
+		}
+	}
+
+	pkgs := make(map[string][]string, len(files))
+	for pkg, set := range files {
+		pkgs[pkg] = slices.Sorted(maps.Keys(set))
+	}
+	return pkgs
+}
+
+func newTable(data []byte, text uint64) (*Table, error) {
+	t, err := gosym.NewTable(nil, gosym.NewLineTable(data, text))
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Funcs) == 0 {
+		return nil, errNoPclntab
+	}
+	return &Table{Table: t, data: data}, nil
+}
+
+// magics are the first words of the pclntab headers of the Go versions since 1.2.
+var magics = []uint32{0xfffffff1, 0xfffffff0, 0xfffffffa, 0xfffffffb}
+
+// search looks for the pclntab header in the data. The start of the text is taken
+// from the header if it is unknown, which is only possible since Go 1.18.
+func search(data []byte, text uint64) *Table {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, magic := range magics {
+			word := make([]byte, 4)
+			order.PutUint32(word, magic)
+			for off := 0; ; {
+				i := bytes.Index(data[off:], word)
+				if i < 0 {
+					break
+				}
+				off += i
+				if t := tableAt(data[off:], order, text); t != nil {
+					return t
+				}
+				off += len(word)
+			}
+		}
+	}
+	return nil
+}
+
+func tableAt(data []byte, order binary.ByteOrder, text uint64) *Table {
+	// The magic is followed by two zero bytes, the instruction size quantum
+	// and the pointer size.
+	if len(data) < 8 || data[4] != 0 || data[5] != 0 {
+		return nil
+	}
+	quantum, ptrSize := data[6], int(data[7])
+	if quantum != 1 && quantum != 2 && quantum != 4 || ptrSize != 4 && ptrSize != 8 {
+		return nil
+	}
+	if text == 0 && order.Uint32(data) >= 0xfffffff0 && len(data) >= 8+3*ptrSize {
+		// The header of Go 1.18 has the number of functions and files before it.
+		off := 8 + 2*ptrSize
+		if ptrSize == 8 {
+			text = order.Uint64(data[off:])
+		} else {
+			text = uint64(order.Uint32(data[off:]))
+		}
+	}
+	t, err := newTable(data, text)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// exe is an executable file of one of the supported formats.
+type exe interface {
+	// pclntab returns the data of the pclntab section or symbol and the address
+	// of the text, or nil if there is no such section.
+	pclntab() ([]byte, uint64)
+	// text returns the address of the text, or zero if it is unknown.
+	text() uint64
+	// blocks returns the data the pclntab may be found in.
+	blocks() [][]byte
+	// sections returns the sections with data in the file, excluding the debug info.
+	sections() []section
+	// symbols returns the symbols in the sections, or nil if there is no symbol table.
+	symbols(sections []section) []symbol
+	// read returns the data at the address, as used by the symbols, or nil if
+	// it is not in the file.
+	read(addr, size uint64) []byte
+}
+
+func openExe(r io.ReaderAt) (exe, error) {
+	head := make([]byte, 4)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(head, []byte("\x7fELF")):
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &elfExe{f}, nil
+	case bytes.HasPrefix(head, []byte("MZ")):
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &peExe{f}, nil
+	case isMachO(head):
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		return &machoExe{f}, nil
+	}
+	return nil, errUnknownFormat
+}
+
+func isMachO(head []byte) bool {
+	switch binary.LittleEndian.Uint32(head) {
+	case macho.Magic32, macho.Magic64, 0xcefaedfe, 0xcffaedfe:
+		return true
+	}
+	return false
+}
+
+type elfExe struct {
+	f *elf.File
+}
+
+func (e *elfExe) pclntab() ([]byte, uint64) {
+	for _, name := range []string{".gopclntab", ".data.rel.ro.gopclntab"} {
+		if s := e.f.Section(name); s != nil && s.Type != elf.SHT_NOBITS {
+			if data, err := s.Data(); err == nil {
+				return data, e.text()
+			}
+		}
+	}
+	return nil, 0
+}
+
+func (e *elfExe) text() uint64 {
+	if s := e.f.Section(".text"); s != nil {
+		return s.Addr
+	}
+	return 0
+}
+
+func (e *elfExe) read(addr, size uint64) []byte {
+	for _, s := range e.f.Sections {
+		if s.Type != elf.SHT_NOBITS && s.Addr <= addr && addr+size <= s.Addr+s.Size {
+			return readAt(s, addr-s.Addr, size)
+		}
+	}
+	return nil
+}
+
+func (e *elfExe) blocks() [][]byte {
+	var blocks [][]byte
+	for _, p := range e.f.Progs {
+		if p.Type == elf.PT_LOAD && p.Flags&elf.PF_X == 0 {
+			if data, err := io.ReadAll(p.Open()); err == nil {
+				blocks = append(blocks, data)
+			}
+		}
+	}
+	return blocks
+}
+
+// The type of the Mach-O sections without data in the file.
+const (
+	sectionType = 0xff
+	zerofill    = 0x1
+)
+
+type machoExe struct {
+	f *macho.File
+}
+
+func (m *machoExe) pclntab() ([]byte, uint64) {
+	if s := m.f.Section("__gopclntab"); s != nil {
+		if data, err := s.Data(); err == nil {
+			return data, m.text()
+		}
+	}
+	return nil, 0
+}
+
+func (m *machoExe) text() uint64 {
+	if s := m.f.Section("__text"); s != nil {
+		return s.Addr
+	}
+	return 0
+}
+
+func (m *machoExe) read(addr, size uint64) []byte {
+	for _, s := range m.f.Sections {
+		if s.Flags&sectionType != zerofill && s.Addr <= addr && addr+size <= s.Addr+s.Size {
+			return readAt(s, addr-s.Addr, size)
+		}
+	}
+	return nil
+}
+
+func (m *machoExe) blocks() [][]byte {
+	var blocks [][]byte
+	for _, s := range m.f.Sections {
+		if s.Name != "__text" && s.Flags&sectionType != zerofill {
+			if data, err := s.Data(); err == nil {
+				blocks = append(blocks, data)
+			}
+		}
+	}
+	return blocks
+}
+
+type peExe struct {
+	f *pe.File
+}
+
+func (p *peExe) pclntab() ([]byte, uint64) {
+	start, end := p.symbol("runtime.pclntab"), p.symbol("runtime.epclntab")
+	if start == nil || end == nil || start.SectionNumber != end.SectionNumber || start.Value > end.Value {
+		return nil, 0
+	}
+	i := int(start.SectionNumber) - 1
+	if i < 0 || i >= len(p.f.Sections) {
+		return nil, 0
+	}
+	data, err := p.f.Sections[i].Data()
+	if err != nil || int(end.Value) > len(data) {
+		return nil, 0
+	}
+	return data[start.Value:end.Value], p.text()
+}
+
+func (p *peExe) symbol(name string) *pe.Symbol {
+	for _, s := range p.f.Symbols {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (p *peExe) text() uint64 {
+	s := p.f.Section(".text")
+	if s == nil {
+		return 0
+	}
+	switch h := p.f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return uint64(h.ImageBase) + uint64(s.VirtualAddress)
+	case *pe.OptionalHeader64:
+		return h.ImageBase + uint64(s.VirtualAddress)
+	}
+	return uint64(s.VirtualAddress)
+}
+
+func (p *peExe) read(addr, size uint64) []byte {
+	for _, s := range p.f.Sections {
+		start := uint64(s.VirtualAddress)
+		if start <= addr && addr+size <= start+uint64(s.Size) {
+			return readAt(s, addr-start, size)
+		}
+	}
+	return nil
+}
+
+func (p *peExe) blocks() [][]byte {
+	var blocks [][]byte
+	// The older linkers put the pclntab into the text section.
+	for _, s := range p.f.Sections {
+		if data, err := s.Data(); err == nil {
+			blocks = append(blocks, data)
+		}
+	}
+	return blocks
+}
+
+func readAt(r io.ReaderAt, off, size uint64) []byte {
+	if r == nil {
+		return nil
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, int64(off)); err != nil {
+		return nil
+	}
+	return data
+}
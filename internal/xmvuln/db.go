@@ -0,0 +1,238 @@
+package xmvuln
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stdlib is the module path used by the Go vulnerability database for the standard library.
+const Stdlib = "stdlib"
+
+var errNoEntries = errors.New("no OSV entries found")
+
+// Entry is the subset of an OSV entry (https://ossf.github.io/osv-schema/) used by goxm.
+type Entry struct {
+	ID        string     `json:"id"`
+	Summary   string     `json:"summary"`
+	Details   string     `json:"details"`
+	Aliases   []string   `json:"aliases"`
+	Withdrawn *time.Time `json:"withdrawn"`
+	Affected  []Affected `json:"affected"`
+
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+type Affected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges []Range `json:"ranges"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// DB is a Go vulnerability database in the layout served by https://vuln.go.dev,
+// read from a local directory or a zip file of it. It is safe for concurrent use.
+type DB struct {
+	fsys  fs.FS
+	close func() error
+
+	// ids are the IDs of the entries affecting each module.
+	ids map[string][]string
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+type indexModule struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// Open opens the database at the given directory or zip file. Databases without the
+// index/modules.json file are supported too, all their entries are read upfront then.
+func Open(name string) (*DB, error) {
+	name = strings.TrimPrefix(name, "file://")
+	stat, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{ids: map[string][]string{}, entries: map[string]*Entry{}}
+	if stat.IsDir() {
+		db.fsys = os.DirFS(name)
+	} else {
+		z, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, err
+		}
+		db.fsys, db.close = z, z.Close
+	}
+
+	if err := db.readIndex(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = db.readAll()
+		}
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// Close closes the zip file of the database. It does nothing for a nil database.
+func (db *DB) Close() error {
+	if db == nil || db.close == nil {
+		return nil
+	}
+	return db.close()
+}
+
+func (db *DB) readIndex() error {
+	data, err := fs.ReadFile(db.fsys, "index/modules.json")
+	if err != nil {
+		return err
+	}
+	var mods []indexModule
+	if err := json.Unmarshal(data, &mods); err != nil {
+		return fmt.Errorf("index/modules.json: %w", err)
+	}
+	for _, m := range mods {
+		for _, v := range m.Vulns {
+			db.ids[m.Path] = append(db.ids[m.Path], v.ID)
+		}
+	}
+	return nil
+}
+
+func (db *DB) readAll() error {
+	err := fs.WalkDir(db.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(name) != ".json" || strings.HasPrefix(name, "index/") {
+			return nil
+		}
+		e, err := db.read(name)
+		if err != nil {
+			return err
+		}
+		db.entries[e.ID] = e
+		for _, mod := range e.modules() {
+			db.ids[mod] = append(db.ids[mod], e.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(db.entries) == 0 {
+		return errNoEntries
+	}
+	return nil
+}
+
+// Vulns returns the entries affecting the given version of the module.
+// The version must be a semantic version with the "v" prefix.
+func (db *DB) Vulns(modpath, version string) ([]*Entry, error) {
+	var found []*Entry
+	for _, id := range db.ids[modpath] {
+		e, err := db.entry(id)
+		if err != nil {
+			return nil, err
+		}
+		if e.Withdrawn != nil {
+			continue
+		}
+		if e.affects(modpath, version) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+func (db *DB) entry(id string) (*Entry, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if e, ok := db.entries[id]; ok {
+		return e, nil
+	}
+	e, err := db.read("ID/" + id + ".json")
+	if err != nil {
+		return nil, err
+	}
+	db.entries[id] = e
+	return e, nil
+}
+
+func (db *DB) read(name string) (*Entry, error) {
+	data, err := fs.ReadFile(db.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return e, nil
+}
+
+func (e *Entry) modules() []string {
+	var mods []string
+	for _, a := range e.Affected {
+		mods = append(mods, a.Package.Name)
+	}
+	return mods
+}
+
+// Fixed returns the lowest version fixing the entry for the given module version,
+// or an empty string if there is no fix.
+func (e *Entry) Fixed(modpath, version string) string {
+	for _, a := range e.Affected {
+		if a.Package.Name != modpath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			for _, ev := range sortedEvents(r.Events) {
+				if ev.Fixed != "" && compare(version, ev.Fixed) < 0 {
+					return "v" + ev.Fixed
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// URL returns the page describing the entry.
+func (e *Entry) URL() string {
+	if e.DatabaseSpecific.URL != "" {
+		return e.DatabaseSpecific.URL
+	}
+	return "https://pkg.go.dev/vuln/" + e.ID
+}
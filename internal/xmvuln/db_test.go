@@ -0,0 +1,247 @@
+package xmvuln
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDB = "testdata/vulndb"
+
+func TestInRange(t *testing.T) {
+	// The events are out of order on purpose, they are evaluated in version order.
+	twoRanges := []Event{{Introduced: "1.3.0"}, {Fixed: "1.3.4"}, {Introduced: "0"}, {Fixed: "1.2.0"}}
+	lastAffected := []Event{{Introduced: "0.9.0"}, {LastAffected: "1.0.0"}}
+	prerelease := []Event{{Introduced: "0"}, {Fixed: "1.20.9"}, {Introduced: "1.21.0-0"}, {Fixed: "1.21.2"}}
+
+	tests := []struct {
+		events  []Event
+		version string
+		want    bool
+	}{
+		{twoRanges, "v0.0.1", true},
+		{twoRanges, "v1.1.9", true},
+		{twoRanges, "v1.2.0", false},
+		{twoRanges, "v1.2.5", false},
+		{twoRanges, "v1.3.0", true},
+		{twoRanges, "v1.3.3", true},
+		{twoRanges, "v1.3.4", false},
+		{twoRanges, "v2.0.0", false},
+		{lastAffected, "v0.8.0", false},
+		{lastAffected, "v0.9.0", true},
+		{lastAffected, "v1.0.0", true},
+		{lastAffected, "v1.0.1", false},
+		{prerelease, "v1.20.8", true},
+		{prerelease, "v1.20.9", false},
+		{prerelease, "v1.21.0-rc.1", true},
+		{prerelease, "v1.21.1", true},
+		{prerelease, "v1.21.2", false},
+		{[]Event{{Introduced: "0"}}, "v0.0.0-20200101000000-abcdefabcdef", true},
+		{nil, "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := inRange(tt.events, tt.version); got != tt.want {
+			t.Errorf("inRange(%v, %s) = %v, want %v", tt.events, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	tests := []struct {
+		goVersion string
+		want      string
+	}{
+		{"go1.21.3", "v1.21.3"},
+		{"go1.21", "v1.21.0"},
+		{"go1.22rc1", "v1.22.0-rc.1"},
+		{"go1.9beta2", "v1.9.0-beta.2"},
+		{"go1.24.4 X:nocoverageredesign", "v1.24.4"},
+		{" go1.20.1\n", "v1.20.1"},
+		{"devel go1.23-e8ee1dc4 Mon Jun 3 10:00:00 2024 +0000", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := GoVersion(tt.goVersion); got != tt.want {
+			t.Errorf("GoVersion(%q) = %q, want %q", tt.goVersion, got, tt.want)
+		}
+	}
+}
+
+func TestVulns(t *testing.T) {
+	db, err := Open(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testVulns(t, db)
+}
+
+func TestVulnsZip(t *testing.T) {
+	db, err := Open(zipDB(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testVulns(t, db)
+	if err := db.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestVulnsWithoutIndex(t *testing.T) {
+	db, err := Open("file://" + zipDB(t, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testVulns(t, db)
+}
+
+func testVulns(t *testing.T, db *DB) {
+	t.Helper()
+	tests := []struct {
+		path, version string
+		want          []string
+	}{
+		// The withdrawn GO-2023-0004 is never reported.
+		{"example.com/lib", "v1.1.0", []string{"GO-2023-0001"}},
+		{"example.com/lib", "v1.0.0", []string{"GO-2023-0001", "GO-2024-0005"}},
+		{"example.com/lib", "v1.2.0", nil},
+		{"example.com/lib", "v1.3.1", []string{"GO-2023-0001"}},
+		{"example.com/other", "v1.0.0", nil},
+		// The binaries are checked against the standard library of their Go version,
+		// the entries of the go command and the other tools are not reported for them.
+		{Stdlib, GoVersion("go1.21.1"), []string{"GO-2023-0002"}},
+		{Stdlib, GoVersion("go1.21.4"), nil},
+		{Stdlib, GoVersion("go1.21rc2"), []string{"GO-2023-0002"}},
+		{"toolchain", GoVersion("go1.21.4"), []string{"GO-2023-0003"}},
+	}
+	for _, tt := range tests {
+		entries, err := db.Vulns(tt.path, tt.version)
+		if err != nil {
+			t.Fatalf("Vulns(%s, %s): %v", tt.path, tt.version, err)
+		}
+		var ids []string
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("Vulns(%s, %s) = %q, want %q", tt.path, tt.version, ids, tt.want)
+		}
+	}
+}
+
+func TestEntry(t *testing.T) {
+	db, err := Open(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	entries, err := db.Vulns("example.com/lib", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Vulns = %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if want := []string{"CVE-2023-1111", "GHSA-aaaa-bbbb-cccc"}; !reflect.DeepEqual(e.Aliases, want) {
+		t.Errorf("%s aliases = %q, want %q", e.ID, e.Aliases, want)
+	}
+	if got := e.URL(); got != "https://pkg.go.dev/vuln/GO-2023-0001" {
+		t.Errorf("%s URL = %q", e.ID, got)
+	}
+	if got := entries[1].URL(); got != "https://pkg.go.dev/vuln/GO-2024-0005" {
+		t.Errorf("%s URL = %q", entries[1].ID, got)
+	}
+
+	fixed := []struct {
+		path, version string
+		want          string
+	}{
+		{"example.com/lib", "v1.0.0", "v1.2.0"},
+		{"example.com/lib", "v1.3.0", "v1.3.4"},
+		{"example.com/lib", "v1.4.0", ""},
+		{"example.com/other", "v1.0.0", ""},
+	}
+	for _, tt := range fixed {
+		if got := e.Fixed(tt.path, tt.version); got != tt.want {
+			t.Errorf("Fixed(%s, %s) = %q, want %q", tt.path, tt.version, got, tt.want)
+		}
+	}
+	// The entry without a fixed event has no fix.
+	if got := entries[1].Fixed("example.com/lib", "v1.0.0"); got != "" {
+		t.Errorf("%s Fixed = %q, want none", entries[1].ID, got)
+	}
+
+	std, err := db.Vulns(Stdlib, "v1.21.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(std) != 1 {
+		t.Fatalf("stdlib Vulns = %d entries, want 1", len(std))
+	}
+	if got := std[0].Fixed(Stdlib, "v1.21.1"); got != "v1.21.2" {
+		t.Errorf("stdlib Fixed = %q, want v1.21.2", got)
+	}
+	if got := std[0].Fixed(Stdlib, "v1.20.0"); got != "v1.20.9" {
+		t.Errorf("stdlib Fixed = %q, want v1.20.9", got)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing database: error = %v, want not exist", err)
+	}
+	if _, err := Open(t.TempDir()); err != errNoEntries {
+		t.Errorf("empty database: error = %v, want %v", err, errNoEntries)
+	}
+	name := filepath.Join(t.TempDir(), "db.zip")
+	if err := os.WriteFile(name, []byte("not a zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(name); err == nil {
+		t.Error("invalid zip: Open succeeded, want error")
+	}
+}
+
+// zipDB writes the test database into a zip file, optionally without its index.
+func zipDB(t *testing.T, noIndex bool) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "vulndb.zip")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	err = fs.WalkDir(os.DirFS(testDB), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || noIndex && strings.HasPrefix(path, "index/") {
+			return err
+		}
+		w, err := zw.Create(path)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(filepath.Join(testDB, path))
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(w, src)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
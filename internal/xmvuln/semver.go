@@ -0,0 +1,97 @@
+package xmvuln
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// affects reports whether the module version is in one of the SEMVER ranges of the entry.
+func (e *Entry) affects(modpath, version string) bool {
+	for _, a := range e.Affected {
+		if a.Package.Name != modpath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type == "SEMVER" && inRange(r.Events, version) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inRange evaluates the events of an OSV range in version order,
+// see https://ossf.github.io/osv-schema/#evaluation.
+func inRange(events []Event, version string) bool {
+	affected := false
+	for _, ev := range sortedEvents(events) {
+		switch {
+		case ev.Introduced != "":
+			if compare(version, ev.Introduced) >= 0 {
+				affected = true
+			}
+		case ev.Fixed != "":
+			if compare(version, ev.Fixed) >= 0 {
+				affected = false
+			}
+		case ev.LastAffected != "":
+			if compare(version, ev.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func sortedEvents(events []Event) []Event {
+	return slices.SortedStableFunc(slices.Values(events), func(a, b Event) int {
+		return compare("v"+a.version(), b.version())
+	})
+}
+
+func (ev Event) version() string {
+	switch {
+	case ev.Introduced != "":
+		return ev.Introduced
+	case ev.Fixed != "":
+		return ev.Fixed
+	}
+	return ev.LastAffected
+}
+
+// compare compares the version with the "v" prefix to the OSV one without it.
+// The OSV version "0" is lower than any other version.
+func compare(version, osv string) int {
+	if osv == "0" {
+		if version == "v0" {
+			return 0
+		}
+		return 1
+	}
+	return semver.Compare(version, "v"+osv)
+}
+
+var goVersionRE = regexp.MustCompile(`^go(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:(rc|beta)(\d+))?`)
+
+// GoVersion converts a Go release version like "go1.21.3" or "go1.22rc1"
+// to a semantic version like "v1.21.3" or "v1.22.0-rc.1". It returns an
+// empty string for development versions.
+func GoVersion(v string) string {
+	m := goVersionRE.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return ""
+	}
+	for i := 2; i <= 3; i++ {
+		if m[i] == "" {
+			m[i] = "0"
+		}
+	}
+	s := "v" + m[1] + "." + m[2] + "." + m[3]
+	if m[4] != "" {
+		s += "-" + m[4] + "." + m[5]
+	}
+	return semver.Canonical(s)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,6 +10,9 @@ import (
 
 func main() {
 	if err := commands.NewRootCmd().Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: [%T] %v\n", err, err)
+		if !errors.Is(err, commands.ErrViolations) {
+			fmt.Fprintf(os.Stderr, "error: [%T] %v\n", err, err)
+		}
+		os.Exit(commands.ExitCode(err))
 	}
 }